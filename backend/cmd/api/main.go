@@ -11,8 +11,10 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/piplexa/algomap/internal/executor"
 	"github.com/piplexa/algomap/internal/handlers"
 	authmiddleware "github.com/piplexa/algomap/internal/middleware"
+	"github.com/piplexa/algomap/internal/nodes"
 	"github.com/piplexa/algomap/internal/repository"
 	"github.com/piplexa/algomap/pkg/config"
 	"github.com/piplexa/algomap/pkg/logger"
@@ -90,11 +92,21 @@ func main() {
 	schemaRepo := repository.NewSchemaRepository(db, logger.Log)
 	executionRepo := repository.NewExecutionRepository(db, logger.Log)
 
+	// Обработчики нод для выполнения отдельной ноды (POST /executions/:id/:id-node/one)
+	// Ноды управления потоком (sleep, sub_schema, parallel, join, foreach) работают только внутри выполнения
+	dataSources, err := nodes.OpenDataSources(cfg.DataSources)
+	if err != nil {
+		logger.Fatal("Failed to open data sources", zap.Error(err))
+	}
+	defer nodes.CloseDataSources(dataSources)
+	registry := nodes.NewRunnableRegistry(logger.Log, dataSources, rmqPublisher)
+	nodeRunner := executor.NewNodeRunner(registry, logger.Log)
+
 	// 6. Создаём handlers
 	userHandler := handlers.NewUserHandler(userRepo, logger.Log)
 	authHandler := handlers.NewAuthHandler(userRepo, sessionRepo, logger.Log)
 	schemaHandler := handlers.NewSchemaHandler(schemaRepo, logger.Log)
-	executionHandler := handlers.NewExecutionHandler(executionRepo, schemaRepo, logger.Log, rmqPublisher, queueName)
+	executionHandler := handlers.NewExecutionHandler(executionRepo, schemaRepo, logger.Log, rmqPublisher, queueName, nodeRunner)
 
 	// 7. Создаём middleware
 	authMw := authmiddleware.NewAuthMiddleware(sessionRepo, logger.Log)
@@ -151,14 +163,33 @@ func main() {
 			r.Get("/schemas", schemaHandler.List)
 			r.Post("/schemas", schemaHandler.Create)
 			r.Get("/schemas/{id}", schemaHandler.GetByID)
+			r.Get("/schemas/{id}/analysis", schemaHandler.Analysis)
+			r.Get("/schemas/{id}/versions", schemaHandler.Versions)
+			r.Get("/schemas/{id}/versions/{version}", schemaHandler.Version)
+			r.Post("/schemas/{id}/versions/{version}/rollback", schemaHandler.Rollback)
+			r.Get("/schemas/{id}/diff", schemaHandler.Diff)
+			r.Post("/schemas/{id}/nodes/{id-node}/run", executionHandler.RunSchemaNode)
 			r.Put("/schemas/{id}", schemaHandler.Update)
 			r.Delete("/schemas/{id}", schemaHandler.Delete)
+			r.Get("/node-types", schemaHandler.NodeTypes)
+			r.Get("/node-types/{type}", schemaHandler.NodeType)
 
 			// Executions
 			r.Post("/executions", executionHandler.Create)
 			r.Get("/executions/{id}", executionHandler.GetByID)
 			r.Get("/executions/{id}/steps", executionHandler.GetSteps)
 			r.Get("/executions/{id}/state", executionHandler.GetState)
+			r.Post("/executions/{id}/pause", executionHandler.Pause)
+			r.Post("/executions/{id}/resume", executionHandler.Resume)
+			r.Post("/executions/{id}/stop", executionHandler.Stop)
+			r.Post("/executions/{id}/retry", executionHandler.Retry)
+			r.Post("/executions/{id}/steps/{stepId}/fork", executionHandler.Fork)
+			r.Post("/executions/{id}/debug/step", executionHandler.DebugStep)
+			r.Post("/executions/{id}/debug/continue", executionHandler.DebugContinue)
+			r.Get("/executions/{id}/debug/variables", executionHandler.DebugVariables)
+			r.Put("/executions/{id}/debug/variables", executionHandler.SetDebugVariables)
+			r.Post("/executions/{id}/debug/abort", executionHandler.Stop)
+			r.Post("/executions/{id}/{id-node}/one", executionHandler.RunNode)
 			r.Get("/executions/list/{id}", executionHandler.GetExecutionsBySchemaID)
 			r.Delete("/executions/schema/{id}", executionHandler.DeleteBySchemaID)
 
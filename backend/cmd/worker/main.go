@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"go.uber.org/zap"
@@ -52,20 +53,38 @@ func main() {
 
 	logger.Info("connected to database")
 
-	// Создаём реестр обработчиков нод
-	registry := nodes.NewHandlerRegistry()
-	registry.Register("start", nodes.NewStartHandler())
-	registry.Register("end", nodes.NewEndHandler())
-	registry.Register("log", nodes.NewLogHandler(logger.Log))
-	registry.Register("variable_set", nodes.NewVariableSetHandler())
-	registry.Register("math", nodes.NewMathHandler())
+	// Отдельное подключение к RabbitMQ для нод, публикующих сообщения из схем
+	rmqConn, err := rabbitmq.NewConnection(cfg.RabbitMQURL, logger.Log)
+	if err != nil {
+		logger.Fatal("failed to connect to RabbitMQ", zap.Error(err))
+	}
+	defer rmqConn.Close()
+	rmqPublisher := rabbitmq.NewPublisher(rmqConn, logger.Log)
+
+	// Подключения для db_query ноды (DATASOURCE_<NAME>_URL)
+	dataSources, err := nodes.OpenDataSources(cfg.DataSources)
+	if err != nil {
+		logger.Fatal("failed to open data sources", zap.Error(err))
+	}
+	defer nodes.CloseDataSources(dataSources)
+	for name := range dataSources {
+		logger.Info("data source registered", zap.String("data_source", name))
+	}
+
+	// Создаём реестр обработчиков нод: общие с API и ноды управления потоком
+	registry := nodes.NewRunnableRegistry(logger.Log, dataSources, rmqPublisher)
 	registry.Register("sleep", nodes.NewSleepHandler(cfg.ATSchedulerURL, cfg.URLExecution, logger.Log))
-	registry.Register("http_request", nodes.NewHTTPRequestHandler())
-	registry.Register("condition", nodes.NewConditionHandler())
+	registry.Register("foreach", nodes.NewForeachHandler())
+	registry.Register("parallel", nodes.NewParallelHandler())
+	registry.Register("join", nodes.NewJoinHandler())
+	registry.Register("sub_schema", nodes.NewSubSchemaHandler(cfg.SubSchemaMaxDepth))
 	// TODO: Добавить остальные обработчики
 
 	// Создаём движок выполнения
-	engine := executor.NewEngine(db, logger.Log, registry)
+	engine := executor.NewEngine(db, logger.Log, registry, executor.ExecutionLimits{
+		MaxSteps:    int64(cfg.ExecutionMaxSteps),
+		MaxDuration: time.Duration(cfg.ExecutionMaxDuration) * time.Second,
+	})
 
 	// Создаём consumer
 	consumer, err := rabbitmq.NewConsumer(cfg.RabbitMQURL, logger.Log, engine)
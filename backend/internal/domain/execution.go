@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -20,6 +22,7 @@ type Execution_view struct {
 type Execution struct {
 	ID              string                 `json:"id" db:"id"`
 	SchemaID        int64                  `json:"schema_id" db:"schema_id"`
+	SchemaVersion   *int                   `json:"schema_version,omitempty" db:"schema_version"` // версия схемы, с которой запущено выполнение
 	StatusID        int16                  `json:"status_id" db:"id_status"`
 	StatusName      string                 `json:"status_name,omitempty"` // для JOIN с dict_execution_status
 	TriggerTypeID   int16                  `json:"trigger_type_id" db:"id_trigger_type"`
@@ -30,9 +33,46 @@ type Execution struct {
 	FinishedAt      *time.Time             `json:"finished_at,omitempty" db:"finished_at"`
 	CreatedAt       time.Time              `json:"created_at" db:"created_at"`
 	CreatedBy       int64                  `json:"created_by" db:"created_by"`
-	Error           *string                `json:"error,omitempty" db:"error"`
+	Error           *ExecutionError        `json:"error,omitempty" db:"error"`
+	Output          interface{}            `json:"output,omitempty" db:"output"` // итоговый результат (output end ноды)
+	Control         *string                `json:"control,omitempty" db:"control"`  // pause - выполнение на паузе (или встаёт на паузу)
+	PausedNodes     json.RawMessage        `json:"paused_nodes,omitempty" db:"paused_nodes"` // сообщения, отложенные до resume
+	DebugMode       bool                   `json:"debug_mode" db:"debug_mode"`
+
+	// Связь с родительским выполнением (для вызова через sub_schema)
+	ParentExecutionID *string `json:"parent_execution_id,omitempty" db:"parent_execution_id"`
+	ParentNodeID      *string `json:"parent_node_id,omitempty" db:"parent_node_id"`
+	Depth             int     `json:"depth" db:"depth"`
+
+	// Форк: выполнение создано из шага другого выполнения
+	ForkedFromExecutionID *string `json:"forked_from_execution_id,omitempty" db:"forked_from_execution_id"`
+	ForkedFromStepID      *int64  `json:"forked_from_step_id,omitempty" db:"forked_from_step_id"`
+}
+
+// ExecutionError структурированная ошибка выполнения (main.executions.error)
+// По Code клиенты API различают бизнес-ошибки (error нода) и технические сбои
+type ExecutionError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+	NodeID  string      `json:"node_id,omitempty"` // нода, на которой упало выполнение
 }
 
+// Коды ошибок выполнения, которые выставляет сам движок (и end нода)
+// Коды error нод задаются в схеме
+const (
+	ErrorCodeNodeFailed        = "node_failed"         // нода завершилась ошибкой без error выхода
+	ErrorCodeInvalidConfig     = "invalid_config"      // конфигурация ноды не соответствует схеме её типа
+	ErrorCodeNodeNotFound      = "node_not_found"      // нода из сообщения отсутствует в схеме
+	ErrorCodeUnknownNodeType   = "unknown_node_type"   // для типа ноды нет обработчика
+	ErrorCodeStepLimitExceeded = "step_limit_exceeded" // превышен лимит шагов
+	ErrorCodeTimeLimitExceeded = "time_limit_exceeded" // превышена максимальная длительность выполнения
+	ErrorCodeNodeTimeout       = "node_timeout"        // нода не уложилась в свой таймаут, а error выхода нет
+	ErrorCodeEndFailed         = "end_failed"          // выполнение завершено end нодой с success: false
+	ErrorCodeStopped           = "stopped"             // выполнение остановлено пользователем
+	ErrorCodePublishFailed     = "publish_failed"      // не удалось отправить выполнение в очередь
+)
+
 // ExecutionState представляет текущее состояние выполнения
 type ExecutionState struct {
 	ExecutionID   string                 `json:"execution_id" db:"execution_id"`
@@ -59,6 +99,15 @@ type ExecutionStep struct {
 	Context		 map[string]interface{} `json:"context,omitempty" db:"context"`
 }
 
+// StepContext контекст выполнения, сохранённый в шаге (после выполнения ноды шага)
+type StepContext struct {
+	StepID      int64           `json:"step_id"`
+	ExecutionID string          `json:"execution_id"`
+	SchemaID    int64           `json:"schema_id"`
+	NodeID      string          `json:"node_id"`
+	Context     json.RawMessage `json:"context"`
+}
+
 // Константы для статусов выполнения
 const (
 	ExecutionStatusPending   int16 = 1
@@ -69,12 +118,59 @@ const (
 	ExecutionStatusStopped   int16 = 6
 )
 
+// Команды управления выполнением (main.executions.control)
+const (
+	ExecutionControlPause = "pause"
+	ExecutionControlStep  = "step" // отладка: остановка перед каждой нодой
+)
+
+// Действия управления выполнением, которые записываются в историю шагов
+// Такие шаги имеют node_type = control и статус skipped
+const (
+	NodeTypeControl     = "control"
+	ControlActionPause  = "pause"
+	ControlActionResume = "resume"
+	ControlActionStop   = "stop"
+	ControlActionParked = "parked" // воркер отложил ноду, потому что выполнение на паузе или остановлено отладчиком
+
+	// Отладка
+	ControlActionStep         = "step"          // выполнить следующую ноду и остановиться
+	ControlActionContinue     = "continue"      // выполнять до следующего breakpoint
+	ControlActionSetVariables = "set_variables" // переменные изменены во время остановки
+
+	ControlActionRetry = "retry" // упавшее выполнение перезапущено с упавшей ноды
+	ControlActionFork  = "fork"  // выполнение создано из шага другого выполнения
+)
+
+// Причины, по которым воркер отложил ноду (output шага parked)
+const (
+	ParkReasonPause      = "pause"
+	ParkReasonStep       = "step"
+	ParkReasonBreakpoint = "breakpoint"
+)
+
+// Ошибки управления выполнением
+var (
+	ErrExecutionNotFound  = errors.New("execution not found")
+	ErrExecutionFinished  = errors.New("execution already finished")
+	ErrExecutionNotPaused = errors.New("execution is not paused")
+	ErrExecutionNotDebug  = errors.New("execution is not in debug mode")
+	ErrStepNotFound       = errors.New("execution step not found")
+	ErrExecutionNotFailed = errors.New("execution is not failed")
+	// ErrExecutionNotRetryable - ошибка не привязана к ноде (например, publish_failed)
+	// или это дочернее выполнение, родитель которого уже продолжил работу
+	ErrExecutionNotRetryable = errors.New("execution cannot be retried")
+	// ErrStepNotForkable - у шага нет следующей ноды (end, упавшая нода без error выхода, шаг управления)
+	ErrStepNotForkable = errors.New("step has no next node to fork from")
+)
+
 // Константы для типов триггеров
 const (
 	TriggerTypeManual    int16 = 1
 	TriggerTypeWebhook   int16 = 2
 	TriggerTypeScheduler int16 = 3
 	TriggerTypeAPI       int16 = 4
+	TriggerTypeSubSchema int16 = 5
 )
 
 // Константы для статусов шагов
@@ -82,6 +178,7 @@ const (
 	StepStatusSuccess int16 = 1
 	StepStatusFailed  int16 = 2
 	StepStatusSkipped int16 = 3
+	StepStatusTimeout int16 = 4
 )
 
 // CreateExecutionResponse - ответ при создании execution
@@ -7,6 +7,29 @@ type CreateExecutionRequest struct {
 	SchemaID       int64           `json:"schema_id" binding:"required"`
 	TriggerPayload json.RawMessage `json:"trigger_payload,omitempty"`
 	DebugMode      bool            `json:"debug_mode,omitempty"`
+	// DebugStep в режиме отладки останавливаться перед каждой нодой (иначе - только на breakpoint нодах)
+	DebugStep bool `json:"debug_step,omitempty"`
+}
+
+// RunNodeRequest - запрос на выполнение одной ноды отдельно от схемы
+// Контекст: context, иначе контекст шага step_id, иначе текущий контекст выполнения (или пустой)
+type RunNodeRequest struct {
+	Context json.RawMessage `json:"context,omitempty"`
+	StepID  *int64          `json:"step_id,omitempty"`
+}
+
+// RetryExecutionRequest - запрос на перезапуск упавшего выполнения
+type RetryExecutionRequest struct {
+	// Variables заменяют значения переменных в восстановленном контексте (остальные переменные сохраняются)
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// ForkExecutionRequest - запрос на создание выполнения из шага другого выполнения
+type ForkExecutionRequest struct {
+	// Variables заменяют значения переменных в контексте шага (остальные переменные сохраняются)
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	DebugMode bool                   `json:"debug_mode,omitempty"`
+	DebugStep bool                   `json:"debug_step,omitempty"`
 }
 
 // Константы для статусов схем (для проверки в handlers)
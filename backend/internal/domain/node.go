@@ -13,7 +13,15 @@ const (
 	NodeTypeVariableSet    = "variable_set"
 	NodeTypeMath           = "math"
 	NodeTypeRabbitMQPublish = "rabbitmq_publish"
-	NodeTypeSubSchema      = "sub_schema" // TODO: будет реализовано позже
+	NodeTypeSubSchema      = "sub_schema"
+	NodeTypeSwitch         = "switch"
+	NodeTypeForeach        = "foreach"
+	NodeTypeParallel       = "parallel"
+	NodeTypeJoin           = "join"
+	NodeTypeJSONTransform  = "json_transform"
+	NodeTypeStringOps      = "string_ops"
+	NodeTypeDBQuery        = "db_query"
+	NodeTypeError          = "error"
 )
 
 // NodeConfig базовая структура для конфигурации ноды
@@ -22,13 +30,4 @@ type NodeConfig struct {
 	Config json.RawMessage `json:"config"`
 }
 
-// TODO: Добавить специфичные конфиги для каждого типа нод:
-// - StartConfig
-// - EndConfig
-// - ConditionConfig
-// - HTTPRequestConfig
-// - LogConfig
-// - SleepConfig
-// и т.д.
-
-// TODO: Реализовать в worker'е
\ No newline at end of file
+// Описание конфигурации каждого типа нод - в node_config.go
@@ -0,0 +1,507 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Типы полей конфигурации ноды
+const (
+	FieldTypeString  = "string"
+	FieldTypeNumber  = "number"
+	FieldTypeInteger = "integer"
+	FieldTypeBoolean = "boolean"
+	FieldTypeObject  = "object"
+	FieldTypeArray   = "array"
+	FieldTypeAny     = "any" // любое значение, в т.ч. шаблон "{{...}}"
+)
+
+// NodeConfigField описание поля конфигурации ноды
+type NodeConfigField struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Required    bool              `json:"required,omitempty"`
+	Enum        []string          `json:"enum,omitempty"`
+	Max         int64             `json:"max,omitempty"` // для integer: максимальное значение, 0 - без ограничения
+	Default     interface{}       `json:"default,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Fields      []NodeConfigField `json:"fields,omitempty"` // для object: вложенные поля
+	Items       *NodeConfigField  `json:"items,omitempty"`  // для array: описание элемента
+}
+
+// NodeTypeSchema описание типа ноды: форма конфигурации и выходы
+// Используется воркером перед выполнением ноды, API при сохранении схемы и редактором для построения форм
+type NodeTypeSchema struct {
+	Type          string            `json:"type"`
+	Label         string            `json:"label"`
+	Fields        []NodeConfigField `json:"fields"`
+	Exits         []string          `json:"exits"`                    // sourceHandle выходов ("output" - выход по умолчанию)
+	RequiredExits []string          `json:"required_exits,omitempty"` // выходы, из которых обязательно должно идти ребро
+	DynamicExits  string            `json:"dynamic_exits,omitempty"`  // поле конфигурации, из которого берутся дополнительные выходы
+	Timeout       int               `json:"timeout"`                  // таймаут выполнения ноды по умолчанию в секундах
+}
+
+// DefaultNodeTimeout таймаут выполнения ноды в секундах, если у типа ноды он не задан
+const DefaultNodeTimeout = 30
+
+// NodeConfigIssue ошибка в конфигурации ноды
+type NodeConfigIssue struct {
+	Field   string `json:"field,omitempty"` // путь к полю: "retry.max_attempts", "operations[1].op"
+	Message string `json:"message"`
+}
+
+// NodeConfigError конфигурация ноды не соответствует схеме её типа
+type NodeConfigError struct {
+	NodeType string
+	Issues   []NodeConfigIssue
+}
+
+func (e *NodeConfigError) Error() string {
+	parts := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		if issue.Field != "" {
+			parts[i] = issue.Field + ": " + issue.Message
+		} else {
+			parts[i] = issue.Message
+		}
+	}
+	return fmt.Sprintf("invalid %s config: %s", e.NodeType, strings.Join(parts, "; "))
+}
+
+// nodeTypeSchemas реестр типов нод (порядок - как в палитре редактора)
+var nodeTypeSchemas = []NodeTypeSchema{
+	{
+		Type:          NodeTypeStart,
+		Label:         "Start",
+		Exits:         []string{"output"},
+		RequiredExits: []string{"output"},
+	},
+	{
+		Type:  NodeTypeEnd,
+		Label: "End",
+		Fields: []NodeConfigField{
+			{Name: "success", Type: FieldTypeBoolean, Default: true, Description: "успешное или неуспешное завершение"},
+			{Name: "message", Type: FieldTypeString, Description: "сообщение, интерполируется"},
+			{Name: "output", Type: FieldTypeObject, Description: "итоговый результат выполнения"},
+		},
+		Exits: []string{},
+	},
+	{
+		Type:  NodeTypeError,
+		Label: "Error",
+		Fields: []NodeConfigField{
+			{Name: "code", Type: FieldTypeString, Required: true, Description: "код ошибки"},
+			{Name: "message", Type: FieldTypeString, Description: "сообщение, интерполируется"},
+			{Name: "details", Type: FieldTypeAny, Description: "детали ошибки, интерполируются"},
+		},
+		Exits: []string{},
+	},
+	{
+		Type:  NodeTypeCondition,
+		Label: "Condition",
+		Fields: []NodeConfigField{
+			{Name: "expression", Type: FieldTypeString, Required: true, Description: "{{x}} > 10 && status in [\"new\", \"paid\"]"},
+		},
+		Exits:         []string{"true", "false", "error"},
+		RequiredExits: []string{"true", "false"},
+	},
+	{
+		Type:  NodeTypeSwitch,
+		Label: "Switch",
+		Fields: []NodeConfigField{
+			{Name: "expression", Type: FieldTypeString, Required: true, Description: "значение для сравнения: {{variables.status}}"},
+			{Name: "cases", Type: FieldTypeArray, Required: true, Items: &NodeConfigField{
+				Type: FieldTypeObject,
+				Fields: []NodeConfigField{
+					{Name: "handle", Type: FieldTypeString, Description: "выход для этой ветки, по умолчанию case_<индекс>"},
+					{Name: "type", Type: FieldTypeString, Enum: []string{"equals", "regex", "range"}, Default: "equals"},
+					{Name: "value", Type: FieldTypeAny, Description: "для equals"},
+					{Name: "pattern", Type: FieldTypeString, Description: "для regex"},
+					{Name: "min", Type: FieldTypeNumber, Description: "для range, включительно"},
+					{Name: "max", Type: FieldTypeNumber, Description: "для range, не включительно"},
+				},
+			}},
+		},
+		Exits:        []string{"default", "error"},
+		DynamicExits: "cases.handle",
+	},
+	{
+		Type:  NodeTypeForeach,
+		Label: "Foreach",
+		Fields: []NodeConfigField{
+			{Name: "items", Type: FieldTypeAny, Required: true, Description: "массив или {{steps.http_1.output.body.items}}"},
+			{Name: "max_iterations", Type: FieldTypeInteger, Description: "ограничение количества итераций"},
+		},
+		Exits:         []string{"body", "done", "error"},
+		RequiredExits: []string{"body"},
+	},
+	{
+		Type:          NodeTypeParallel,
+		Label:         "Parallel",
+		Exits:         []string{"output"},
+		RequiredExits: []string{"output"},
+	},
+	{
+		Type:  NodeTypeJoin,
+		Label: "Join",
+		Fields: []NodeConfigField{
+			{Name: "mode", Type: FieldTypeString, Enum: []string{"all", "count"}, Default: "all"},
+			{Name: "count", Type: FieldTypeInteger, Description: "для mode = count: сколько веток дождаться"},
+		},
+		Exits: []string{"output"},
+	},
+	{
+		Type:  NodeTypeHTTPRequest,
+		Label: "HTTP Request",
+		Fields: []NodeConfigField{
+			{Name: "method", Type: FieldTypeString, Enum: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}, Default: "GET"},
+			{Name: "url", Type: FieldTypeString, Required: true},
+			{Name: "headers", Type: FieldTypeObject},
+			{Name: "body", Type: FieldTypeAny},
+			{Name: "timeout", Type: FieldTypeInteger, Default: 30, Description: "секунды"},
+			{Name: "retry", Type: FieldTypeObject, Fields: []NodeConfigField{
+				{Name: "max_attempts", Type: FieldTypeInteger, Required: true},
+				{Name: "delay", Type: FieldTypeInteger, Description: "секунды между попытками"},
+				{Name: "status_codes", Type: FieldTypeArray, Items: &NodeConfigField{Type: FieldTypeInteger}},
+			}},
+		},
+		Exits: []string{"success", "error"},
+		// timeout в конфигурации - на одну попытку, нода целиком должна уложиться и с повторами
+		Timeout: 120,
+	},
+	{
+		Type:  NodeTypeLog,
+		Label: "Log",
+		Fields: []NodeConfigField{
+			{Name: "message", Type: FieldTypeString, Description: "сообщение, интерполируется"},
+			{Name: "level", Type: FieldTypeString, Enum: []string{"debug", "info", "warn", "error"}, Default: "info"},
+		},
+		Exits: []string{"output"},
+	},
+	{
+		Type:  NodeTypeVariableSet,
+		Label: "Variable Set",
+		Fields: []NodeConfigField{
+			{Name: "variable", Type: FieldTypeString, Required: true},
+			{Name: "value", Type: FieldTypeAny},
+		},
+		Exits: []string{"output"},
+	},
+	{
+		Type:  NodeTypeSleep,
+		Label: "Sleep",
+		Fields: []NodeConfigField{
+			{Name: "duration", Type: FieldTypeInteger, Required: true},
+			{Name: "unit", Type: FieldTypeString, Enum: []string{"seconds", "minutes", "hours"}, Default: "seconds"},
+		},
+		Exits: []string{"output"},
+	},
+	{
+		Type:  NodeTypeMath,
+		Label: "Math",
+		Fields: []NodeConfigField{
+			{Name: "formula", Type: FieldTypeString, Description: "round(({{price}} * {{qty}}) * 1.2, 2)"},
+			{Name: "operation", Type: FieldTypeString, Enum: []string{"add", "subtract", "multiply", "divide", "modulo"}, Description: "если formula не задана"},
+			{Name: "operand1", Type: FieldTypeAny},
+			{Name: "operand2", Type: FieldTypeAny},
+			{Name: "result_variable", Type: FieldTypeString},
+			{Name: "decimal", Type: FieldTypeBoolean, Default: false, Description: "точная десятичная арифметика"},
+		},
+		Exits: []string{"success", "error"},
+	},
+	{
+		Type:  NodeTypeJSONTransform,
+		Label: "JSON Transform",
+		Fields: []NodeConfigField{
+			{Name: "source", Type: FieldTypeAny, Required: true},
+			{Name: "operations", Type: FieldTypeArray, Required: true, Items: &NodeConfigField{
+				Type: FieldTypeObject,
+				Fields: []NodeConfigField{
+					{Name: "op", Type: FieldTypeString, Required: true, Enum: []string{"get", "pick", "rename", "map", "filter", "flatten", "merge"}},
+					{Name: "path", Type: FieldTypeString},
+					{Name: "fields", Type: FieldTypeArray, Items: &NodeConfigField{Type: FieldTypeString}},
+					{Name: "mapping", Type: FieldTypeObject},
+					{Name: "template", Type: FieldTypeAny},
+					{Name: "condition", Type: FieldTypeString},
+					{Name: "depth", Type: FieldTypeInteger},
+					{Name: "separator", Type: FieldTypeString},
+					{Name: "with", Type: FieldTypeAny},
+					{Name: "deep", Type: FieldTypeBoolean},
+				},
+			}},
+			{Name: "result_variable", Type: FieldTypeString},
+		},
+		Exits: []string{"success", "error"},
+	},
+	{
+		Type:  NodeTypeStringOps,
+		Label: "String Ops",
+		Fields: []NodeConfigField{
+			{Name: "input", Type: FieldTypeAny, Required: true},
+			{Name: "operations", Type: FieldTypeArray, Required: true, Items: &NodeConfigField{
+				Type: FieldTypeObject,
+				Fields: []NodeConfigField{
+					{Name: "op", Type: FieldTypeString, Required: true, Enum: []string{
+						"split", "join", "replace", "regex_match", "regex_replace",
+						"upper", "lower", "title", "trim", "pad", "substring", "format",
+					}},
+					{Name: "separator", Type: FieldTypeString},
+					{Name: "limit", Type: FieldTypeInteger},
+					{Name: "old", Type: FieldTypeString},
+					{Name: "new", Type: FieldTypeString},
+					{Name: "pattern", Type: FieldTypeString},
+					{Name: "replacement", Type: FieldTypeString},
+					{Name: "group", Type: FieldTypeString},
+					{Name: "all", Type: FieldTypeBoolean},
+					{Name: "chars", Type: FieldTypeString},
+					{Name: "side", Type: FieldTypeString, Enum: []string{"left", "right", "both"}},
+					{Name: "length", Type: FieldTypeInteger, Max: 10000},
+					{Name: "char", Type: FieldTypeString},
+					{Name: "start", Type: FieldTypeInteger},
+					{Name: "end", Type: FieldTypeInteger},
+					{Name: "format", Type: FieldTypeString},
+					{Name: "args", Type: FieldTypeArray, Items: &NodeConfigField{Type: FieldTypeAny}},
+				},
+			}},
+			{Name: "result_variable", Type: FieldTypeString},
+		},
+		Exits: []string{"success", "error"},
+	},
+	{
+		Type:  NodeTypeDBQuery,
+		Label: "DB Query",
+		Fields: []NodeConfigField{
+			{Name: "data_source", Type: FieldTypeString, Required: true, Description: "имя подключения (DATASOURCE_<NAME>_URL)"},
+			{Name: "query", Type: FieldTypeString, Required: true},
+			{Name: "mode", Type: FieldTypeString, Enum: []string{"select", "exec"}, Default: "select"},
+			{Name: "max_rows", Type: FieldTypeInteger, Default: 1000},
+		},
+		Exits: []string{"success", "error"},
+	},
+	{
+		Type:  NodeTypeRabbitMQPublish,
+		Label: "RabbitMQ Publish",
+		Fields: []NodeConfigField{
+			{Name: "queue", Type: FieldTypeString},
+			{Name: "exchange", Type: FieldTypeString},
+			{Name: "routing_key", Type: FieldTypeString},
+			{Name: "message", Type: FieldTypeAny, Required: true},
+			{Name: "headers", Type: FieldTypeObject},
+			{Name: "persistent", Type: FieldTypeBoolean, Default: true},
+			{Name: "confirm", Type: FieldTypeBoolean, Default: false},
+			{Name: "content_type", Type: FieldTypeString},
+		},
+		Exits: []string{"success", "error"},
+	},
+	{
+		Type:  NodeTypeSubSchema,
+		Label: "Sub Schema",
+		Fields: []NodeConfigField{
+			{Name: "schema_id", Type: FieldTypeInteger, Required: true},
+			{Name: "input_mapping", Type: FieldTypeObject, Description: "переменные дочерней схемы"},
+		},
+		Exits: []string{"success", "error"},
+	},
+}
+
+// Тип ноды без своего таймаута получает таймаут по умолчанию
+func init() {
+	for i := range nodeTypeSchemas {
+		if nodeTypeSchemas[i].Timeout == 0 {
+			nodeTypeSchemas[i].Timeout = DefaultNodeTimeout
+		}
+	}
+}
+
+// NodeTypeSchemas возвращает описания всех типов нод
+func NodeTypeSchemas() []NodeTypeSchema {
+	return nodeTypeSchemas
+}
+
+// GetNodeTypeSchema возвращает описание типа ноды
+func GetNodeTypeSchema(nodeType string) (*NodeTypeSchema, bool) {
+	for i := range nodeTypeSchemas {
+		if nodeTypeSchemas[i].Type == nodeType {
+			return &nodeTypeSchemas[i], true
+		}
+	}
+	return nil, false
+}
+
+// ValidateNodeConfig проверяет конфигурацию ноды по схеме её типа
+// Возвращает конфигурацию с подставленными значениями по умолчанию или *NodeConfigError
+// Поля, не описанные в схеме, не проверяются и сохраняются как есть
+func ValidateNodeConfig(nodeType string, config json.RawMessage) (json.RawMessage, error) {
+	schema, ok := GetNodeTypeSchema(nodeType)
+	if !ok {
+		return nil, &NodeConfigError{
+			NodeType: nodeType,
+			Issues:   []NodeConfigIssue{{Message: fmt.Sprintf("unknown node type: %s", nodeType)}},
+		}
+	}
+
+	var values map[string]interface{}
+	if len(bytes.TrimSpace(config)) > 0 {
+		// UseNumber - чтобы отличать целые числа от дробных
+		decoder := json.NewDecoder(bytes.NewReader(config))
+		decoder.UseNumber()
+		var raw interface{}
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, &NodeConfigError{
+				NodeType: nodeType,
+				Issues:   []NodeConfigIssue{{Message: fmt.Sprintf("invalid JSON: %v", err)}},
+			}
+		}
+		if raw != nil {
+			obj, ok := raw.(map[string]interface{})
+			if !ok {
+				return nil, &NodeConfigError{
+					NodeType: nodeType,
+					Issues:   []NodeConfigIssue{{Message: "config must be an object"}},
+				}
+			}
+			values = obj
+		}
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+
+	var issues []NodeConfigIssue
+	validateConfigFields(schema.Fields, values, "", &issues)
+	if len(issues) > 0 {
+		return nil, &NodeConfigError{NodeType: nodeType, Issues: issues}
+	}
+
+	return json.Marshal(values)
+}
+
+// validateConfigFields проверяет поля объекта и подставляет значения по умолчанию
+func validateConfigFields(fields []NodeConfigField, values map[string]interface{}, prefix string, issues *[]NodeConfigIssue) {
+	for _, field := range fields {
+		path := prefix + field.Name
+		value, present := values[field.Name]
+		// Пустая строка в поле с перечислением (не выбранный select) - то же, что отсутствие значения
+		if len(field.Enum) > 0 && value == "" {
+			present = false
+		}
+		if !present || value == nil {
+			switch {
+			case field.Default != nil:
+				values[field.Name] = field.Default
+			case field.Required:
+				*issues = append(*issues, NodeConfigIssue{Field: path, Message: "is required"})
+			}
+			continue
+		}
+		if field.Required && value == "" {
+			*issues = append(*issues, NodeConfigIssue{Field: path, Message: "is required"})
+			continue
+		}
+		validateConfigValue(field, value, path, issues)
+	}
+}
+
+// validateConfigValue проверяет тип и допустимые значения поля
+func validateConfigValue(field NodeConfigField, value interface{}, path string, issues *[]NodeConfigIssue) {
+	if !matchesFieldType(field.Type, value) {
+		*issues = append(*issues, NodeConfigIssue{
+			Field:   path,
+			Message: fmt.Sprintf("expected %s, got %s", field.Type, configValueType(value)),
+		})
+		return
+	}
+
+	if len(field.Enum) > 0 {
+		str, _ := value.(string)
+		allowed := false
+		for _, option := range field.Enum {
+			if str == option {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			*issues = append(*issues, NodeConfigIssue{
+				Field:   path,
+				Message: fmt.Sprintf("must be one of: %s", strings.Join(field.Enum, ", ")),
+			})
+			return
+		}
+	}
+
+	if field.Max > 0 {
+		if num, ok := value.(json.Number); ok {
+			if n, err := num.Int64(); err == nil && n > field.Max {
+				*issues = append(*issues, NodeConfigIssue{
+					Field:   path,
+					Message: fmt.Sprintf("must not exceed %d", field.Max),
+				})
+				return
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(field.Fields) > 0 {
+			validateConfigFields(field.Fields, v, path+".", issues)
+		}
+	case []interface{}:
+		if field.Items != nil {
+			for i, item := range v {
+				validateConfigValue(*field.Items, item, fmt.Sprintf("%s[%d]", path, i), issues)
+			}
+		}
+	}
+}
+
+// matchesFieldType проверяет соответствие значения типу поля
+func matchesFieldType(fieldType string, value interface{}) bool {
+	switch fieldType {
+	case FieldTypeString:
+		_, ok := value.(string)
+		return ok
+	case FieldTypeNumber:
+		_, ok := value.(json.Number)
+		return ok
+	case FieldTypeInteger:
+		num, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := num.Int64()
+		return err == nil
+	case FieldTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case FieldTypeObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case FieldTypeArray:
+		_, ok := value.([]interface{})
+		return ok
+	default:
+		return true
+	}
+}
+
+// configValueType название JSON типа значения для сообщений об ошибках
+func configValueType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return "null"
+	}
+}
@@ -12,30 +12,51 @@ type Schema struct {
 	Description string          `json:"description"`
 	Definition  json.RawMessage `json:"definition"` // JSONB с нодами и рёбрами
 	Status      int16           `json:"status"`     // 1=draft, 2=active, 3=archived
+	Version     int             `json:"version"`    // текущая версия definition (main.schema_versions)
 	CreatedBy   int64           `json:"created_by"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
+
+	// Проблемы в definition (заполняется при сохранении, в БД не хранится)
+	Issues []SchemaIssue `json:"issues,omitempty"`
 }
 
-// SchemaDefinition - структура для валидации definition (опционально)
+// SchemaDefinition - структура для валидации definition (формат React Flow)
 type SchemaDefinition struct {
-	Nodes []Node `json:"nodes"`
-	Edges []Edge `json:"edges"`
+	Nodes    []Node         `json:"nodes"`
+	Edges    []Edge         `json:"edges"`
+	Settings SchemaSettings `json:"settings"`
+}
+
+// SchemaSettings - настройки выполнения схемы (0 - лимит пользователя или значение по умолчанию)
+type SchemaSettings struct {
+	MaxSteps    int64 `json:"max_steps,omitempty"`
+	MaxDuration int   `json:"max_duration,omitempty"` // секунд
 }
 
 // Node представляет ноду в схеме
 type Node struct {
-	ID     string          `json:"id"`
-	Type   string          `json:"type"`
-	Config json.RawMessage `json:"config"`
+	ID   string   `json:"id"`
+	Type string   `json:"type"` // тип для React Flow
+	Data NodeData `json:"data"`
+}
+
+// NodeData реальный тип ноды и её конфигурация
+type NodeData struct {
+	Type       string          `json:"type"`
+	Label      string          `json:"label"`
+	Config     json.RawMessage `json:"config"`
+	Timeout    int             `json:"timeout,omitempty"`    // таймаут выполнения ноды в секундах
+	Breakpoint bool            `json:"breakpoint,omitempty"` // остановка отладчика перед нодой
 }
 
 // Edge представляет связь между нодами
 type Edge struct {
-	ID     string `json:"id"`
-	Source string `json:"source"` // ID ноды-источника
-	Target string `json:"target"` // ID ноды-назначения
-	Label  string `json:"label"`  // Метка (например, "true", "false", "next")
+	ID           string `json:"id"`
+	Source       string `json:"source"`       // ID ноды-источника
+	Target       string `json:"target"`       // ID ноды-назначения
+	SourceHandle string `json:"sourceHandle"` // выход ноды-источника ("success", "error", "true"...)
+	Label        string `json:"label"`        // Метка (например, "true", "false", "next")
 }
 
 // CreateSchemaRequest - запрос на создание схемы
@@ -43,6 +64,7 @@ type CreateSchemaRequest struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description"`
 	Definition  json.RawMessage `json:"definition"`
+	Status      *int16          `json:"status,omitempty"` // по умолчанию active, схема с ошибками - draft
 }
 
 // UpdateSchemaRequest - запрос на обновление схемы
@@ -51,4 +73,4 @@ type UpdateSchemaRequest struct {
 	Description *string          `json:"description,omitempty"`
 	Definition  *json.RawMessage `json:"definition,omitempty"`
 	Status      *int16           `json:"status,omitempty"`
-}
\ No newline at end of file
+}
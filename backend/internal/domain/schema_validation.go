@@ -0,0 +1,256 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Уровни проблем в схеме
+const (
+	IssueSeverityError   = "error"   // схему нельзя активировать
+	IssueSeverityWarning = "warning" // схема работает, но, скорее всего, не так, как задумано
+)
+
+// Коды проблем в схеме
+const (
+	IssueNoStartNode        = "no_start_node"
+	IssueMultipleStartNodes = "multiple_start_nodes"
+	IssueDuplicateNodeID    = "duplicate_node_id"
+	IssueUnknownNodeType    = "unknown_node_type"
+	IssueInvalidConfig      = "invalid_config"
+	IssueDanglingEdge       = "dangling_edge"
+	IssueMissingExit        = "missing_exit"
+	IssueUnknownExit        = "unknown_exit"
+	IssueUnreachableNode    = "unreachable_node"
+	IssueInvalidSettings    = "invalid_settings"
+)
+
+// SchemaIssue проблема в definition схемы
+type SchemaIssue struct {
+	Severity string `json:"severity"` // error, warning
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	NodeID   string `json:"node_id,omitempty"`
+	EdgeID   string `json:"edge_id,omitempty"`
+	Field    string `json:"field,omitempty"` // для invalid_config - путь к полю, для missing_exit - выход
+}
+
+// HasErrors есть ли среди проблем ошибки (не только предупреждения)
+func HasErrors(issues []SchemaIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == IssueSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDefinition проверяет граф схемы: start нода, типы и конфигурации нод,
+// рёбра, обязательные выходы, достижимость нод из start и настройки схемы
+func ValidateDefinition(def *SchemaDefinition) []SchemaIssue {
+	issues := make([]SchemaIssue, 0)
+	addIssue := func(severity, code, message string, nodeID, edgeID, field string) {
+		issues = append(issues, SchemaIssue{
+			Severity: severity,
+			Code:     code,
+			Message:  message,
+			NodeID:   nodeID,
+			EdgeID:   edgeID,
+			Field:    field,
+		})
+	}
+
+	// 1. Ноды: уникальность ID, известный тип, конфигурация
+	nodesByID := make(map[string]*Node, len(def.Nodes))
+	var startNodes []string
+	for i := range def.Nodes {
+		node := &def.Nodes[i]
+		if _, exists := nodesByID[node.ID]; exists {
+			addIssue(IssueSeverityError, IssueDuplicateNodeID,
+				fmt.Sprintf("duplicate node id: %s", node.ID), node.ID, "", "")
+			continue
+		}
+		nodesByID[node.ID] = node
+
+		nodeType := DefinitionNodeType(node)
+		if nodeType == NodeTypeStart {
+			startNodes = append(startNodes, node.ID)
+		}
+		if _, ok := GetNodeTypeSchema(nodeType); !ok {
+			addIssue(IssueSeverityError, IssueUnknownNodeType,
+				fmt.Sprintf("unknown node type: %s", nodeType), node.ID, "", "")
+			continue
+		}
+		if _, err := ValidateNodeConfig(nodeType, node.Data.Config); err != nil {
+			if configErr, ok := err.(*NodeConfigError); ok {
+				for _, configIssue := range configErr.Issues {
+					addIssue(IssueSeverityError, IssueInvalidConfig, configIssue.Message, node.ID, "", configIssue.Field)
+				}
+			}
+		}
+		if node.Data.Timeout < 0 {
+			addIssue(IssueSeverityError, IssueInvalidConfig, "timeout must not be negative", node.ID, "", "timeout")
+		}
+	}
+
+	// 2. Ровно одна start нода
+	switch {
+	case len(startNodes) == 0:
+		addIssue(IssueSeverityError, IssueNoStartNode, "schema has no start node", "", "", "")
+	case len(startNodes) > 1:
+		for _, id := range startNodes[1:] {
+			addIssue(IssueSeverityError, IssueMultipleStartNodes,
+				fmt.Sprintf("schema must have exactly one start node, found %d", len(startNodes)), id, "", "")
+		}
+	}
+
+	// 3. Рёбра: обе ноды существуют, выход известен типу ноды-источника
+	outgoing := make(map[string][]Edge)
+	for _, edge := range def.Edges {
+		source, sourceOK := nodesByID[edge.Source]
+		_, targetOK := nodesByID[edge.Target]
+		if !sourceOK || !targetOK {
+			missing := edge.Source
+			if sourceOK {
+				missing = edge.Target
+			}
+			addIssue(IssueSeverityError, IssueDanglingEdge,
+				fmt.Sprintf("edge references nonexistent node: %s", missing), "", edge.ID, "")
+			continue
+		}
+		outgoing[edge.Source] = append(outgoing[edge.Source], edge)
+
+		if handle := edgeHandle(edge); !hasExit(source, handle) {
+			addIssue(IssueSeverityWarning, IssueUnknownExit,
+				fmt.Sprintf("node %s has no exit %s", source.ID, handle), source.ID, edge.ID, handle)
+		}
+	}
+
+	// 4. Обязательные выходы
+	for i := range def.Nodes {
+		node := &def.Nodes[i]
+		schema, ok := GetNodeTypeSchema(DefinitionNodeType(node))
+		if !ok {
+			continue
+		}
+		for _, handle := range schema.RequiredExits {
+			if !hasEdgeForExit(outgoing[node.ID], handle) {
+				addIssue(IssueSeverityError, IssueMissingExit,
+					fmt.Sprintf("node %s has no edge for exit %s", node.ID, handle), node.ID, "", handle)
+			}
+		}
+	}
+
+	// 5. Достижимость из start
+	if len(startNodes) == 1 {
+		reachable := map[string]bool{startNodes[0]: true}
+		queue := []string{startNodes[0]}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, edge := range outgoing[current] {
+				if !reachable[edge.Target] {
+					reachable[edge.Target] = true
+					queue = append(queue, edge.Target)
+				}
+			}
+		}
+		for _, node := range def.Nodes {
+			if !reachable[node.ID] {
+				addIssue(IssueSeverityWarning, IssueUnreachableNode,
+					fmt.Sprintf("node %s is not reachable from start", node.ID), node.ID, "", "")
+			}
+		}
+	}
+
+	// 6. Настройки схемы
+	if def.Settings.MaxSteps < 0 {
+		addIssue(IssueSeverityError, IssueInvalidSettings, "max_steps must not be negative", "", "", "settings.max_steps")
+	}
+	if def.Settings.MaxDuration < 0 {
+		addIssue(IssueSeverityError, IssueInvalidSettings, "max_duration must not be negative", "", "", "settings.max_duration")
+	}
+
+	return issues
+}
+
+// DefinitionNodeType реальный тип ноды (data.type, для старых схем - type)
+func DefinitionNodeType(node *Node) string {
+	if node.Data.Type != "" {
+		return node.Data.Type
+	}
+	return node.Type
+}
+
+// edgeHandle выход, из которого идёт ребро ("" и "output" - выход по умолчанию)
+func edgeHandle(edge Edge) string {
+	if edge.SourceHandle == "" {
+		return "output"
+	}
+	return edge.SourceHandle
+}
+
+// hasEdgeForExit есть ли ребро из выхода (ребро из выхода по умолчанию подходит для любого выхода,
+// для самого выхода по умолчанию подходит любое ребро)
+func hasEdgeForExit(edges []Edge, handle string) bool {
+	if handle == "output" {
+		return len(edges) > 0
+	}
+	for _, edge := range edges {
+		if h := edgeHandle(edge); h == handle || h == "output" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasExit есть ли у ноды выход (с учётом выходов, задаваемых конфигурацией, например switch cases)
+func hasExit(node *Node, handle string) bool {
+	schema, ok := GetNodeTypeSchema(DefinitionNodeType(node))
+	if !ok {
+		return true // о неизвестном типе уже сообщено
+	}
+	if handle == "output" {
+		// Ребро по умолчанию подходит любой ноде, у которой вообще есть выходы (не end/error)
+		return len(schema.Exits) > 0
+	}
+	for _, exit := range schema.Exits {
+		if exit == handle {
+			return true
+		}
+		// Для нод с одним выходом движок сам выбирает success или error по статусу
+		if exit == "output" && (handle == "success" || handle == "error") {
+			return true
+		}
+	}
+	if schema.DynamicExits != "" {
+		for _, exit := range dynamicExits(node) {
+			if exit == handle {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dynamicExits выходы, задаваемые конфигурацией ноды (switch: cases[].handle)
+// Case без handle движок направляет в выход case_<индекс> (см. nodes.SwitchCaseHandle)
+func dynamicExits(node *Node) []string {
+	var config struct {
+		Cases []struct {
+			Handle string `json:"handle"`
+		} `json:"cases"`
+	}
+	if len(node.Data.Config) == 0 || json.Unmarshal(node.Data.Config, &config) != nil {
+		return nil
+	}
+	exits := make([]string, 0, len(config.Cases))
+	for i, c := range config.Cases {
+		if c.Handle == "" {
+			exits = append(exits, fmt.Sprintf("case_%d", i))
+			continue
+		}
+		exits = append(exits, c.Handle)
+	}
+	return exits
+}
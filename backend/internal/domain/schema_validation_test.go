@@ -0,0 +1,356 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func testNode(id, nodeType, config string) Node {
+	node := Node{ID: id, Type: "custom", Data: NodeData{Type: nodeType}}
+	if config != "" {
+		node.Data.Config = json.RawMessage(config)
+	}
+	return node
+}
+
+func testEdge(id, source, target, handle string) Edge {
+	return Edge{ID: id, Source: source, Target: target, SourceHandle: handle}
+}
+
+// issueKey поля проблемы без текста сообщения
+type issueKey struct {
+	Severity string
+	Code     string
+	NodeID   string
+	EdgeID   string
+	Field    string
+}
+
+func issueKeys(issues []SchemaIssue) []issueKey {
+	keys := make([]issueKey, len(issues))
+	for i, issue := range issues {
+		keys[i] = issueKey{issue.Severity, issue.Code, issue.NodeID, issue.EdgeID, issue.Field}
+	}
+	return keys
+}
+
+func TestValidateDefinition(t *testing.T) {
+	tests := []struct {
+		name string
+		def  SchemaDefinition
+		want []issueKey
+	}{
+		{
+			"valid schema",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("check", NodeTypeCondition, `{"expression": "{{variables.x}} > 1"}`),
+					testNode("log", NodeTypeLog, `{"message": "big"}`),
+					testNode("end", NodeTypeEnd, ""),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "check", ""),
+					testEdge("e2", "check", "log", "true"),
+					testEdge("e3", "check", "end", "false"),
+					testEdge("e4", "log", "end", "output"),
+				},
+			},
+			[]issueKey{},
+		},
+		{
+			"no start node",
+			SchemaDefinition{
+				Nodes: []Node{testNode("end", NodeTypeEnd, "")},
+			},
+			[]issueKey{{IssueSeverityError, IssueNoStartNode, "", "", ""}},
+		},
+		{
+			"multiple start nodes",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start_1", NodeTypeStart, ""),
+					testNode("start_2", NodeTypeStart, ""),
+					testNode("end", NodeTypeEnd, ""),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start_1", "end", ""),
+					testEdge("e2", "start_2", "end", ""),
+				},
+			},
+			[]issueKey{{IssueSeverityError, IssueMultipleStartNodes, "start_2", "", ""}},
+		},
+		{
+			"duplicate node id",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("end", NodeTypeEnd, ""),
+					testNode("end", NodeTypeLog, ""),
+				},
+				Edges: []Edge{testEdge("e1", "start", "end", "")},
+			},
+			[]issueKey{{IssueSeverityError, IssueDuplicateNodeID, "end", "", ""}},
+		},
+		{
+			"unknown node type",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("magic", "magic", ""),
+				},
+				Edges: []Edge{testEdge("e1", "start", "magic", "")},
+			},
+			[]issueKey{{IssueSeverityError, IssueUnknownNodeType, "magic", "", ""}},
+		},
+		{
+			"invalid config",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("log", NodeTypeLog, `{"level": "trace"}`),
+					testNode("check", NodeTypeCondition, `{}`),
+					testNode("pad", NodeTypeStringOps, `{"input": "x", "operations": [{"op": "pad", "length": 1000000}]}`),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "log", ""),
+					testEdge("e2", "log", "check", ""),
+					testEdge("e3", "check", "log", "true"),
+					testEdge("e4", "check", "pad", "false"),
+					testEdge("e5", "pad", "log", ""),
+				},
+			},
+			[]issueKey{
+				{IssueSeverityError, IssueInvalidConfig, "log", "", "level"},
+				{IssueSeverityError, IssueInvalidConfig, "check", "", "expression"},
+				{IssueSeverityError, IssueInvalidConfig, "pad", "", "operations[0].length"},
+			},
+		},
+		{
+			"negative node timeout",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					{ID: "log", Data: NodeData{Type: NodeTypeLog, Timeout: -1}},
+				},
+				Edges: []Edge{testEdge("e1", "start", "log", "")},
+			},
+			[]issueKey{{IssueSeverityError, IssueInvalidConfig, "log", "", "timeout"}},
+		},
+		{
+			"dangling edge",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("end", NodeTypeEnd, ""),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "end", ""),
+					testEdge("e2", "start", "ghost", ""),
+					testEdge("e3", "ghost", "end", ""),
+				},
+			},
+			[]issueKey{
+				{IssueSeverityError, IssueDanglingEdge, "", "e2", ""},
+				{IssueSeverityError, IssueDanglingEdge, "", "e3", ""},
+			},
+		},
+		{
+			"missing exit",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("check", NodeTypeCondition, `{"expression": "true"}`),
+					testNode("end", NodeTypeEnd, ""),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "check", ""),
+					testEdge("e2", "check", "end", "true"),
+				},
+			},
+			[]issueKey{{IssueSeverityError, IssueMissingExit, "check", "", "false"}},
+		},
+		{
+			"missing default exit",
+			SchemaDefinition{
+				Nodes: []Node{testNode("start", NodeTypeStart, "")},
+			},
+			[]issueKey{{IssueSeverityError, IssueMissingExit, "start", "", "output"}},
+		},
+		{
+			"default edge covers required exits",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("check", NodeTypeCondition, `{"expression": "true"}`),
+					testNode("end", NodeTypeEnd, ""),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "check", ""),
+					testEdge("e2", "check", "end", "output"),
+				},
+			},
+			[]issueKey{},
+		},
+		{
+			"unknown exit",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("check", NodeTypeCondition, `{"expression": "true"}`),
+					testNode("end", NodeTypeEnd, ""),
+					testNode("log", NodeTypeLog, ""),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "check", ""),
+					testEdge("e2", "check", "end", "true"),
+					testEdge("e3", "check", "end", "false"),
+					testEdge("e4", "check", "end", "maybe"),
+					testEdge("e5", "end", "log", ""),
+				},
+			},
+			[]issueKey{
+				{IssueSeverityWarning, IssueUnknownExit, "check", "e4", "maybe"},
+				{IssueSeverityWarning, IssueUnknownExit, "end", "e5", "output"},
+			},
+		},
+		{
+			"single output node accepts success and error edges",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("log", NodeTypeLog, ""),
+					testNode("ok", NodeTypeEnd, ""),
+					testNode("fail", NodeTypeError, `{"code": "log_failed"}`),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "log", ""),
+					testEdge("e2", "log", "ok", "success"),
+					testEdge("e3", "log", "fail", "error"),
+				},
+			},
+			[]issueKey{},
+		},
+		{
+			"switch dynamic exits",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("route", NodeTypeSwitch, `{"expression": "{{variables.status}}", "cases": [
+						{"handle": "paid", "value": "paid"},
+						{"value": "new"},
+						{"handle": "", "type": "regex", "pattern": "^fail"}
+					]}`),
+					testNode("end", NodeTypeEnd, ""),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "route", ""),
+					testEdge("e2", "route", "end", "paid"),
+					testEdge("e3", "route", "end", "case_1"),
+					testEdge("e4", "route", "end", "case_2"),
+					testEdge("e5", "route", "end", "default"),
+					testEdge("e6", "route", "end", "case_0"),
+				},
+			},
+			[]issueKey{{IssueSeverityWarning, IssueUnknownExit, "route", "e6", "case_0"}},
+		},
+		{
+			"unreachable node",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("end", NodeTypeEnd, ""),
+					testNode("orphan", NodeTypeLog, ""),
+					testNode("orphan_end", NodeTypeEnd, ""),
+				},
+				Edges: []Edge{
+					testEdge("e1", "start", "end", ""),
+					testEdge("e2", "orphan", "orphan_end", ""),
+				},
+			},
+			[]issueKey{
+				{IssueSeverityWarning, IssueUnreachableNode, "orphan", "", ""},
+				{IssueSeverityWarning, IssueUnreachableNode, "orphan_end", "", ""},
+			},
+		},
+		{
+			"invalid settings",
+			SchemaDefinition{
+				Nodes: []Node{
+					testNode("start", NodeTypeStart, ""),
+					testNode("end", NodeTypeEnd, ""),
+				},
+				Edges:    []Edge{testEdge("e1", "start", "end", "")},
+				Settings: SchemaSettings{MaxSteps: -1, MaxDuration: -5},
+			},
+			[]issueKey{
+				{IssueSeverityError, IssueInvalidSettings, "", "", "settings.max_steps"},
+				{IssueSeverityError, IssueInvalidSettings, "", "", "settings.max_duration"},
+			},
+		},
+		{
+			"legacy node type without data.type",
+			SchemaDefinition{
+				Nodes: []Node{
+					{ID: "start", Type: NodeTypeStart},
+					{ID: "end", Type: NodeTypeEnd},
+				},
+				Edges: []Edge{testEdge("e1", "start", "end", "")},
+			},
+			[]issueKey{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := issueKeys(ValidateDefinition(&tt.def))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issues = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		issues []SchemaIssue
+		want   bool
+	}{
+		{"no issues", nil, false},
+		{"only warnings", []SchemaIssue{{Severity: IssueSeverityWarning, Code: IssueUnreachableNode}}, false},
+		{"with error", []SchemaIssue{
+			{Severity: IssueSeverityWarning, Code: IssueUnknownExit},
+			{Severity: IssueSeverityError, Code: IssueMissingExit},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasErrors(tt.issues); got != tt.want {
+				t.Errorf("HasErrors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefinitionNodeType(t *testing.T) {
+	tests := []struct {
+		name string
+		node Node
+		want string
+	}{
+		{"data type", Node{Type: "custom", Data: NodeData{Type: NodeTypeLog}}, NodeTypeLog},
+		{"legacy type", Node{Type: NodeTypeLog}, NodeTypeLog},
+		{"data type wins", Node{Type: NodeTypeStart, Data: NodeData{Type: NodeTypeEnd}}, NodeTypeEnd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefinitionNodeType(&tt.node); got != tt.want {
+				t.Errorf("DefinitionNodeType = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// SchemaVersion неизменяемая версия definition схемы (main.schema_versions)
+type SchemaVersion struct {
+	SchemaID     int64           `json:"schema_id"`
+	Version      int             `json:"version"`
+	Definition   json.RawMessage `json:"definition,omitempty"`    // в списке версий не возвращается
+	RestoredFrom *int            `json:"restored_from,omitempty"` // версия, откат к которой создал эту
+	CreatedBy    int64           `json:"created_by"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// SchemaDiff отличия двух версий definition
+// Расположение нод на холсте не сравнивается
+type SchemaDiff struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+
+	NodesAdded   []Node       `json:"nodes_added"`
+	NodesRemoved []Node       `json:"nodes_removed"`
+	NodesChanged []NodeChange `json:"nodes_changed"`
+
+	EdgesAdded   []Edge `json:"edges_added"`
+	EdgesRemoved []Edge `json:"edges_removed"`
+
+	Settings *SettingsChange `json:"settings,omitempty"` // nil - настройки не менялись
+}
+
+// NodeChange изменённая нода: поля, которые отличаются, и нода до и после
+type NodeChange struct {
+	NodeID string   `json:"node_id"`
+	Fields []string `json:"fields"` // type, label, config
+	Before Node     `json:"before"`
+	After  Node     `json:"after"`
+}
+
+// SettingsChange настройки схемы до и после
+type SettingsChange struct {
+	Before SchemaSettings `json:"before"`
+	After  SchemaSettings `json:"after"`
+}
+
+// DiffDefinitions сравнивает две версии definition
+// Ноды сопоставляются по ID, рёбра - по ID (ребро без ID - по источнику, выходу и цели)
+func DiffDefinitions(from, to *SchemaDefinition) *SchemaDiff {
+	diff := &SchemaDiff{
+		NodesAdded:   make([]Node, 0),
+		NodesRemoved: make([]Node, 0),
+		NodesChanged: make([]NodeChange, 0),
+		EdgesAdded:   make([]Edge, 0),
+		EdgesRemoved: make([]Edge, 0),
+	}
+
+	// 1. Ноды
+	fromNodes := make(map[string]Node, len(from.Nodes))
+	for _, node := range from.Nodes {
+		fromNodes[node.ID] = node
+	}
+	toNodes := make(map[string]bool, len(to.Nodes))
+	for _, node := range to.Nodes {
+		toNodes[node.ID] = true
+		before, ok := fromNodes[node.ID]
+		if !ok {
+			diff.NodesAdded = append(diff.NodesAdded, node)
+			continue
+		}
+		if fields := changedNodeFields(before, node); len(fields) > 0 {
+			diff.NodesChanged = append(diff.NodesChanged, NodeChange{
+				NodeID: node.ID,
+				Fields: fields,
+				Before: before,
+				After:  node,
+			})
+		}
+	}
+	for _, node := range from.Nodes {
+		if !toNodes[node.ID] {
+			diff.NodesRemoved = append(diff.NodesRemoved, node)
+		}
+	}
+
+	// 2. Рёбра: изменённое ребро показывается как удалённое и добавленное
+	fromEdges := make(map[string]Edge, len(from.Edges))
+	for _, edge := range from.Edges {
+		fromEdges[edgeKey(edge)] = edge
+	}
+	toEdges := make(map[string]bool, len(to.Edges))
+	for _, edge := range to.Edges {
+		key := edgeKey(edge)
+		toEdges[key] = true
+		before, ok := fromEdges[key]
+		if !ok {
+			diff.EdgesAdded = append(diff.EdgesAdded, edge)
+			continue
+		}
+		if before.Source != edge.Source || before.Target != edge.Target || edgeHandle(before) != edgeHandle(edge) {
+			diff.EdgesRemoved = append(diff.EdgesRemoved, before)
+			diff.EdgesAdded = append(diff.EdgesAdded, edge)
+		}
+	}
+	for _, edge := range from.Edges {
+		if !toEdges[edgeKey(edge)] {
+			diff.EdgesRemoved = append(diff.EdgesRemoved, edge)
+		}
+	}
+
+	// 3. Настройки
+	if from.Settings != to.Settings {
+		diff.Settings = &SettingsChange{Before: from.Settings, After: to.Settings}
+	}
+
+	return diff
+}
+
+// changedNodeFields поля ноды, которые отличаются
+func changedNodeFields(before, after Node) []string {
+	var fields []string
+	if DefinitionNodeType(&before) != DefinitionNodeType(&after) {
+		fields = append(fields, "type")
+	}
+	if before.Data.Label != after.Data.Label {
+		fields = append(fields, "label")
+	}
+	if !equalJSON(before.Data.Config, after.Data.Config) {
+		fields = append(fields, "config")
+	}
+	return fields
+}
+
+// edgeKey ключ для сопоставления рёбер разных версий
+func edgeKey(edge Edge) string {
+	if edge.ID != "" {
+		return edge.ID
+	}
+	return edge.Source + "|" + edgeHandle(edge) + "|" + edge.Target
+}
+
+// equalJSON сравнивает JSON по значению (порядок ключей и пробелы не важны)
+func equalJSON(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &va); err != nil {
+			return false
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &vb); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,447 @@
+package executor
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/piplexa/algomap/internal/domain"
+	"github.com/piplexa/algomap/internal/nodes"
+)
+
+// SchemaAnalysis отчёт статического анализа схемы
+// В отличие от проверки при сохранении (domain.ValidateDefinition) ничего не запрещает -
+// показывает места, где выполнение, скорее всего, пойдёт не так
+type SchemaAnalysis struct {
+	Cycles             []SchemaCycle       `json:"cycles"`                  // циклы без условия выхода
+	UnreachableNodes   []string            `json:"unreachable_nodes"`       // ноды, недостижимые из start
+	DeadEnds           []string            `json:"dead_ends"`               // ноды, из которых не дойти до end
+	HTTPWithoutError   []string            `json:"http_without_error_edge"` // http_request ноды без ребра из выхода error
+	UndefinedVariables []UndefinedVariable `json:"undefined_variables"`     // переменные, которые никто выше не записывает
+}
+
+// SchemaCycle цикл в графе схемы
+type SchemaCycle struct {
+	Nodes []string `json:"nodes"`
+}
+
+// UndefinedVariable чтение переменной, которую не записывает ни одна нода выше по графу
+type UndefinedVariable struct {
+	NodeID     string `json:"node_id"`
+	Variable   string `json:"variable"`
+	Expression string `json:"expression"` // выражение из {{...}}
+}
+
+// templatePattern выражения {{...}} в конфигурации нод
+var templatePattern = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
+
+// branchingNodeTypes ноды, выбирающие выход по условию - только через них можно выйти из цикла
+var branchingNodeTypes = map[string]bool{
+	domain.NodeTypeCondition: true,
+	domain.NodeTypeSwitch:    true,
+	domain.NodeTypeForeach:   true,
+}
+
+// AnalyzeSchema выполняет статический анализ схемы
+func AnalyzeSchema(schema *domain.SchemaDefinition) *SchemaAnalysis {
+	g := newSchemaGraph(schema)
+
+	analysis := &SchemaAnalysis{
+		Cycles:             g.cyclesWithoutExit(),
+		UnreachableNodes:   make([]string, 0),
+		DeadEnds:           make([]string, 0),
+		HTTPWithoutError:   make([]string, 0),
+		UndefinedVariables: g.undefinedVariables(),
+	}
+
+	// Достижимость из start и до end/error
+	reachable := g.reachable(g.nodesOfType(domain.NodeTypeStart), g.outgoing)
+	canFinish := g.reachable(append(g.nodesOfType(domain.NodeTypeEnd), g.nodesOfType(domain.NodeTypeError)...), g.incoming)
+	for _, id := range g.order {
+		if !reachable[id] {
+			analysis.UnreachableNodes = append(analysis.UnreachableNodes, id)
+			continue
+		}
+		if !canFinish[id] {
+			analysis.DeadEnds = append(analysis.DeadEnds, id)
+		}
+	}
+
+	// HTTP запросы без обработки ошибки: без error ребра упавший запрос уйдёт по ребру
+	// по умолчанию или уронит всё выполнение
+	for _, id := range g.nodesOfType(domain.NodeTypeHTTPRequest) {
+		hasErrorEdge := false
+		for _, edge := range g.edges[id] {
+			if edge.SourceHandle == "error" {
+				hasErrorEdge = true
+				break
+			}
+		}
+		if !hasErrorEdge {
+			analysis.HTTPWithoutError = append(analysis.HTTPWithoutError, id)
+		}
+	}
+
+	return analysis
+}
+
+// schemaGraph граф схемы для анализа
+type schemaGraph struct {
+	order    []string                 // ID нод в порядке definition
+	byID     map[string]*domain.Node  // нода по ID
+	edges    map[string][]domain.Edge // исходящие рёбра
+	outgoing map[string][]string      // ID следующих нод
+	incoming map[string][]string      // ID предыдущих нод
+}
+
+func newSchemaGraph(schema *domain.SchemaDefinition) *schemaGraph {
+	g := &schemaGraph{
+		byID:     make(map[string]*domain.Node),
+		edges:    make(map[string][]domain.Edge),
+		outgoing: make(map[string][]string),
+		incoming: make(map[string][]string),
+	}
+	for i := range schema.Nodes {
+		node := &schema.Nodes[i]
+		if _, exists := g.byID[node.ID]; exists {
+			continue
+		}
+		g.byID[node.ID] = node
+		g.order = append(g.order, node.ID)
+	}
+	for _, edge := range schema.Edges {
+		if g.byID[edge.Source] == nil || g.byID[edge.Target] == nil {
+			continue // висячие рёбра - забота domain.ValidateDefinition
+		}
+		g.edges[edge.Source] = append(g.edges[edge.Source], edge)
+		g.outgoing[edge.Source] = append(g.outgoing[edge.Source], edge.Target)
+		g.incoming[edge.Target] = append(g.incoming[edge.Target], edge.Source)
+	}
+	return g
+}
+
+// nodesOfType ID нод указанного типа
+func (g *schemaGraph) nodesOfType(nodeType string) []string {
+	var ids []string
+	for _, id := range g.order {
+		if domain.DefinitionNodeType(g.byID[id]) == nodeType {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// reachable множество нод, достижимых из from по рёбрам links (сами from тоже входят)
+func (g *schemaGraph) reachable(from []string, links map[string][]string) map[string]bool {
+	seen := make(map[string]bool, len(g.order))
+	queue := make([]string, 0, len(from))
+	for _, id := range from {
+		if !seen[id] {
+			seen[id] = true
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range links[current] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+// cyclesWithoutExit циклы (компоненты сильной связности), из которых нельзя выйти по условию
+// Выход из цикла есть, если хотя бы одна condition/switch/foreach нода цикла может пойти
+// за его пределы или завершить ветку. Выход только через error не считается - без ошибок цикл бесконечен
+func (g *schemaGraph) cyclesWithoutExit() []SchemaCycle {
+	cycles := make([]SchemaCycle, 0)
+	for _, component := range g.stronglyConnected() {
+		inCycle := make(map[string]bool, len(component))
+		for _, id := range component {
+			inCycle[id] = true
+		}
+		if len(component) == 1 && !g.hasSelfLoop(component[0]) {
+			continue // одиночная нода без петли - не цикл
+		}
+
+		hasExit := false
+		for _, id := range component {
+			if g.canLeave(id, inCycle) {
+				hasExit = true
+				break
+			}
+		}
+		if !hasExit {
+			// Ноды цикла - в порядке definition
+			ordered := make([]string, 0, len(component))
+			for _, id := range g.order {
+				if inCycle[id] {
+					ordered = append(ordered, id)
+				}
+			}
+			cycles = append(cycles, SchemaCycle{Nodes: ordered})
+		}
+	}
+	return cycles
+}
+
+// hasSelfLoop есть ли у ноды ребро в саму себя
+func (g *schemaGraph) hasSelfLoop(id string) bool {
+	for _, next := range g.outgoing[id] {
+		if next == id {
+			return true
+		}
+	}
+	return false
+}
+
+// canLeave может ли ветвящаяся нода выбрать выход, ведущий за пределы цикла (или никуда)
+// Выход выбирается так же, как в Engine.findNextNode: ребро с этим sourceHandle, иначе ребро по умолчанию
+func (g *schemaGraph) canLeave(id string, inCycle map[string]bool) bool {
+	node := g.byID[id]
+	if !branchingNodeTypes[domain.DefinitionNodeType(node)] {
+		return false
+	}
+
+	var defaultTarget string
+	specific := make(map[string]string)
+	for _, edge := range g.edges[id] {
+		if edge.SourceHandle == "" || edge.SourceHandle == "output" {
+			if defaultTarget == "" {
+				defaultTarget = edge.Target
+			}
+			continue
+		}
+		if _, exists := specific[edge.SourceHandle]; !exists {
+			specific[edge.SourceHandle] = edge.Target
+		}
+	}
+
+	for _, handle := range branchExits(node) {
+		target, ok := specific[handle]
+		if !ok {
+			target = defaultTarget
+		}
+		if target == "" || !inCycle[target] {
+			return true
+		}
+	}
+	return false
+}
+
+// branchExits выходы ветвящейся ноды, кроме error
+func branchExits(node *domain.Node) []string {
+	switch domain.DefinitionNodeType(node) {
+	case domain.NodeTypeCondition:
+		return []string{"true", "false"}
+	case domain.NodeTypeForeach:
+		return []string{nodes.ForeachBodyHandle, nodes.ForeachDoneHandle}
+	case domain.NodeTypeSwitch:
+		var config nodes.SwitchConfig
+		exits := []string{nodes.SwitchDefaultHandle}
+		if len(node.Data.Config) > 0 && json.Unmarshal(node.Data.Config, &config) == nil {
+			for i, c := range config.Cases {
+				exits = append(exits, nodes.SwitchCaseHandle(c, i))
+			}
+		}
+		return exits
+	}
+	return nil
+}
+
+// stronglyConnected компоненты сильной связности (алгоритм Тарьяна)
+func (g *schemaGraph) stronglyConnected() [][]string {
+	index := 0
+	indices := make(map[string]int, len(g.order))
+	lowlink := make(map[string]int, len(g.order))
+	onStack := make(map[string]bool, len(g.order))
+	var stack []string
+	var components [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		indices[id] = index
+		lowlink[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range g.outgoing[id] {
+			if _, visited := indices[next]; !visited {
+				visit(next)
+				if lowlink[next] < lowlink[id] {
+					lowlink[id] = lowlink[next]
+				}
+			} else if onStack[next] && indices[next] < lowlink[id] {
+				lowlink[id] = indices[next]
+			}
+		}
+
+		if lowlink[id] == indices[id] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == id {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, id := range g.order {
+		if _, visited := indices[id]; !visited {
+			visit(id)
+		}
+	}
+	return components
+}
+
+// undefinedVariables переменные, читаемые через {{...}}, которые не записывает ни одна нода выше по графу
+// Переменные, которые приходят в дочернюю схему через input_mapping sub_schema, тоже попадут в отчёт
+func (g *schemaGraph) undefinedVariables() []UndefinedVariable {
+	result := make([]UndefinedVariable, 0)
+
+	// Какие переменные записывает каждая нода
+	writes := make(map[string][]string, len(g.order))
+	for _, id := range g.order {
+		writes[id] = writtenVariables(g.byID[id])
+	}
+
+	for _, id := range g.order {
+		reads := readVariables(g.byID[id])
+		if len(reads) == 0 {
+			continue
+		}
+
+		// Ноды выше по графу (включая саму ноду, если она в цикле)
+		defined := make(map[string]bool)
+		dynamic := false
+		for upstream := range g.reachable(g.incoming[id], g.incoming) {
+			for _, name := range writes[upstream] {
+				if strings.Contains(name, "{{") {
+					dynamic = true // имя переменной вычисляется - не знаем, какую переменную пишет
+				}
+				defined[name] = true
+			}
+		}
+		if dynamic {
+			continue
+		}
+
+		for _, read := range reads {
+			if !defined[read.Variable] {
+				read.NodeID = id
+				result = append(result, read)
+			}
+		}
+	}
+	return result
+}
+
+// writtenVariables переменные, которые записывает нода (variable_set, math, json_transform, string_ops)
+func writtenVariables(node *domain.Node) []string {
+	var config struct {
+		Variable       string `json:"variable"`
+		ResultVariable string `json:"result_variable"`
+	}
+	if len(node.Data.Config) == 0 || json.Unmarshal(node.Data.Config, &config) != nil {
+		return nil
+	}
+
+	var name string
+	switch domain.DefinitionNodeType(node) {
+	case domain.NodeTypeVariableSet:
+		name = config.Variable
+	case domain.NodeTypeMath, domain.NodeTypeJSONTransform, domain.NodeTypeStringOps:
+		name = config.ResultVariable
+	}
+	if name == "" {
+		return nil
+	}
+	return []string{name, variableRoot(name)}
+}
+
+// readVariables переменные, которые нода читает через {{...}} в своей конфигурации
+// Выражения с "| default:" пропускаются - отсутствие переменной там предусмотрено
+func readVariables(node *domain.Node) []UndefinedVariable {
+	var config interface{}
+	if len(node.Data.Config) == 0 || json.Unmarshal(node.Data.Config, &config) != nil {
+		return nil
+	}
+
+	var reads []UndefinedVariable
+	seen := make(map[string]bool)
+	walkStrings(config, func(s string) {
+		for _, match := range templatePattern.FindAllStringSubmatch(s, -1) {
+			expr := match[1]
+			if strings.Contains(expr, "| default:") || strings.Contains(expr, "|default:") {
+				continue
+			}
+			name, ok := referencedVariable(expr)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			reads = append(reads, UndefinedVariable{Variable: name, Expression: expr})
+		}
+	})
+	return reads
+}
+
+// referencedVariable имя переменной, на которую ссылается путь: variables.x.y -> x, x.y -> x
+func referencedVariable(expr string) (string, bool) {
+	root := variableRoot(expr)
+	if root != "variables" && nodes.IsContextRoot(root) {
+		// webhook, steps, item, loop... - не переменные (корни те же, что у nodes.ResolvePath)
+		return "", false
+	}
+	if root == "variables" {
+		rest := strings.TrimPrefix(strings.TrimSpace(expr), "variables")
+		rest = strings.TrimPrefix(rest, ".")
+		if rest == "" {
+			return "", false
+		}
+		return variableRoot(rest), true
+	}
+	return root, root != ""
+}
+
+// variableRoot первый сегмент пути: "order.items[0]" -> "order"
+func variableRoot(path string) string {
+	path = strings.TrimSpace(path)
+	if end := strings.IndexAny(path, ".[ "); end >= 0 {
+		return path[:end]
+	}
+	return path
+}
+
+// walkStrings вызывает fn для каждой строки внутри значения
+func walkStrings(value interface{}, fn func(string)) {
+	switch v := value.(type) {
+	case string:
+		fn(v)
+	case map[string]interface{}:
+		// Ключи по порядку - чтобы отчёт был стабильным
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkStrings(v[key], fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	}
+}
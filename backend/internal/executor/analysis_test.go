@@ -0,0 +1,327 @@
+package executor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/piplexa/algomap/internal/domain"
+)
+
+func analysisNode(id, nodeType, config string) domain.Node {
+	node := domain.Node{ID: id, Data: domain.NodeData{Type: nodeType}}
+	if config != "" {
+		node.Data.Config = json.RawMessage(config)
+	}
+	return node
+}
+
+func analysisEdge(source, target, handle string) domain.Edge {
+	return domain.Edge{ID: source + "-" + target, Source: source, Target: target, SourceHandle: handle}
+}
+
+func TestAnalyzeSchemaCycles(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []domain.Node
+		edges []domain.Edge
+		want  []SchemaCycle
+	}{
+		{
+			"no cycles",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("log", domain.NodeTypeLog, ""),
+				analysisNode("end", domain.NodeTypeEnd, ""),
+			},
+			[]domain.Edge{analysisEdge("start", "log", ""), analysisEdge("log", "end", "")},
+			[]SchemaCycle{},
+		},
+		{
+			"cycle without branching node",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("a", domain.NodeTypeLog, ""),
+				analysisNode("b", domain.NodeTypeSleep, ""),
+				analysisNode("end", domain.NodeTypeEnd, ""),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "a", ""),
+				analysisEdge("b", "a", ""),
+				analysisEdge("a", "b", ""),
+				analysisEdge("a", "end", "error"),
+			},
+			[]SchemaCycle{{Nodes: []string{"a", "b"}}},
+		},
+		{
+			"self loop",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("poll", domain.NodeTypeHTTPRequest, ""),
+			},
+			[]domain.Edge{analysisEdge("start", "poll", ""), analysisEdge("poll", "poll", "success")},
+			[]SchemaCycle{{Nodes: []string{"poll"}}},
+		},
+		{
+			"condition leaves the cycle",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("check", domain.NodeTypeCondition, ""),
+				analysisNode("wait", domain.NodeTypeSleep, ""),
+				analysisNode("end", domain.NodeTypeEnd, ""),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "check", ""),
+				analysisEdge("check", "wait", "false"),
+				analysisEdge("wait", "check", ""),
+				analysisEdge("check", "end", "true"),
+			},
+			[]SchemaCycle{},
+		},
+		{
+			"condition without edge for exit finishes the branch",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("check", domain.NodeTypeCondition, ""),
+				analysisNode("wait", domain.NodeTypeSleep, ""),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "check", ""),
+				analysisEdge("check", "wait", "false"),
+				analysisEdge("wait", "check", ""),
+			},
+			[]SchemaCycle{},
+		},
+		{
+			"condition with both exits inside the cycle",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("check", domain.NodeTypeCondition, ""),
+				analysisNode("wait", domain.NodeTypeSleep, ""),
+				analysisNode("end", domain.NodeTypeEnd, ""),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "check", ""),
+				analysisEdge("check", "wait", "output"),
+				analysisEdge("wait", "check", ""),
+				analysisEdge("check", "end", "error"),
+			},
+			[]SchemaCycle{{Nodes: []string{"check", "wait"}}},
+		},
+		{
+			"switch leaves through case without handle",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("route", domain.NodeTypeSwitch, `{"expression": "x", "cases": [{"handle": "again", "value": "a"}, {"value": "b"}]}`),
+				analysisNode("wait", domain.NodeTypeSleep, ""),
+				analysisNode("end", domain.NodeTypeEnd, ""),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "route", ""),
+				analysisEdge("route", "wait", "again"),
+				analysisEdge("route", "wait", "default"),
+				analysisEdge("wait", "route", ""),
+				analysisEdge("route", "end", "case_1"),
+			},
+			[]SchemaCycle{},
+		},
+		{
+			"switch with all cases inside the cycle",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("route", domain.NodeTypeSwitch, `{"expression": "x", "cases": [{"handle": "again", "value": "a"}]}`),
+				analysisNode("wait", domain.NodeTypeSleep, ""),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "route", ""),
+				analysisEdge("route", "wait", ""),
+				analysisEdge("wait", "route", ""),
+			},
+			[]SchemaCycle{{Nodes: []string{"route", "wait"}}},
+		},
+		{
+			"foreach body loop",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("loop", domain.NodeTypeForeach, ""),
+				analysisNode("log", domain.NodeTypeLog, ""),
+				analysisNode("end", domain.NodeTypeEnd, ""),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "loop", ""),
+				analysisEdge("loop", "log", "body"),
+				analysisEdge("log", "loop", ""),
+				analysisEdge("loop", "end", "done"),
+			},
+			[]SchemaCycle{},
+		},
+		{
+			"legacy node type",
+			[]domain.Node{
+				{ID: "start", Type: domain.NodeTypeStart},
+				{ID: "check", Type: domain.NodeTypeCondition},
+				{ID: "wait", Type: domain.NodeTypeSleep},
+			},
+			[]domain.Edge{
+				analysisEdge("start", "check", ""),
+				analysisEdge("check", "wait", "true"),
+				analysisEdge("wait", "check", ""),
+			},
+			[]SchemaCycle{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := AnalyzeSchema(&domain.SchemaDefinition{Nodes: tt.nodes, Edges: tt.edges})
+			if !reflect.DeepEqual(analysis.Cycles, tt.want) {
+				t.Errorf("cycles = %v, want %v", analysis.Cycles, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeSchemaReachability(t *testing.T) {
+	schema := &domain.SchemaDefinition{
+		Nodes: []domain.Node{
+			analysisNode("start", domain.NodeTypeStart, ""),
+			analysisNode("http", domain.NodeTypeHTTPRequest, `{"url": "https://example.com"}`),
+			analysisNode("fail", domain.NodeTypeError, `{"code": "http_failed"}`),
+			analysisNode("log", domain.NodeTypeLog, ""),
+			analysisNode("stuck", domain.NodeTypeSleep, ""),
+			analysisNode("end", domain.NodeTypeEnd, ""),
+			analysisNode("orphan", domain.NodeTypeHTTPRequest, `{"url": "https://example.com"}`),
+		},
+		Edges: []domain.Edge{
+			analysisEdge("start", "http", ""),
+			analysisEdge("http", "log", "success"),
+			analysisEdge("http", "fail", "error"),
+			analysisEdge("log", "end", ""),
+			analysisEdge("log", "stuck", "error"),
+			analysisEdge("orphan", "end", ""),
+			{ID: "dangling", Source: "log", Target: "ghost"},
+		},
+	}
+
+	analysis := AnalyzeSchema(schema)
+	if want := []string{"orphan"}; !reflect.DeepEqual(analysis.UnreachableNodes, want) {
+		t.Errorf("unreachable nodes = %v, want %v", analysis.UnreachableNodes, want)
+	}
+	if want := []string{"stuck"}; !reflect.DeepEqual(analysis.DeadEnds, want) {
+		t.Errorf("dead ends = %v, want %v", analysis.DeadEnds, want)
+	}
+	if want := []string{"orphan"}; !reflect.DeepEqual(analysis.HTTPWithoutError, want) {
+		t.Errorf("http without error edge = %v, want %v", analysis.HTTPWithoutError, want)
+	}
+}
+
+func TestAnalyzeSchemaUndefinedVariables(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []domain.Node
+		edges []domain.Edge
+		want  []UndefinedVariable
+	}{
+		{
+			"variable set upstream",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("set", domain.NodeTypeVariableSet, `{"variable": "order", "value": 1}`),
+				analysisNode("log", domain.NodeTypeLog, `{"message": "{{variables.order.id}} {{order}}"}`),
+			},
+			[]domain.Edge{analysisEdge("start", "set", ""), analysisEdge("set", "log", "")},
+			[]UndefinedVariable{},
+		},
+		{
+			"variable set downstream",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("log", domain.NodeTypeLog, `{"message": "total: {{variables.total}}"}`),
+				analysisNode("set", domain.NodeTypeVariableSet, `{"variable": "total", "value": 1}`),
+			},
+			[]domain.Edge{analysisEdge("start", "log", ""), analysisEdge("log", "set", "")},
+			[]UndefinedVariable{{NodeID: "log", Variable: "total", Expression: "variables.total"}},
+		},
+		{
+			"result variables of math, json_transform and string_ops",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("math", domain.NodeTypeMath, `{"formula": "1 + 1", "result_variable": "sum"}`),
+				analysisNode("transform", domain.NodeTypeJSONTransform, `{"source": {}, "result_variable": "doc"}`),
+				analysisNode("text", domain.NodeTypeStringOps, `{"input": "x", "result_variable": "text"}`),
+				analysisNode("log", domain.NodeTypeLog, `{"message": "{{sum}} {{doc.a}} {{text}}"}`),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "math", ""),
+				analysisEdge("math", "transform", ""),
+				analysisEdge("transform", "text", ""),
+				analysisEdge("text", "log", ""),
+			},
+			[]UndefinedVariable{},
+		},
+		{
+			"nested result variable defines its root",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("set", domain.NodeTypeVariableSet, `{"variable": "order.status", "value": "new"}`),
+				analysisNode("log", domain.NodeTypeLog, `{"message": "{{variables.order.id}}"}`),
+			},
+			[]domain.Edge{analysisEdge("start", "set", ""), analysisEdge("set", "log", "")},
+			[]UndefinedVariable{},
+		},
+		{
+			"variable set in a loop",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("check", domain.NodeTypeCondition, `{"expression": "{{variables.attempt}} < 3"}`),
+				analysisNode("set", domain.NodeTypeVariableSet, `{"variable": "attempt", "value": 1}`),
+			},
+			[]domain.Edge{
+				analysisEdge("start", "set", ""),
+				analysisEdge("set", "check", ""),
+				analysisEdge("check", "set", "true"),
+			},
+			[]UndefinedVariable{},
+		},
+		{
+			"default filter and other roots are skipped",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("log", domain.NodeTypeLog, `{"message": "{{variables.missing | default: 0}} {{webhook.payload.id}} {{steps.start.output}} {{execution.id}} {{user.id}} {{item}} {{index}} {{loop.foreach_1.item}}"}`),
+			},
+			[]domain.Edge{analysisEdge("start", "log", "")},
+			[]UndefinedVariable{},
+		},
+		{
+			"dynamic variable name upstream",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("set", domain.NodeTypeVariableSet, `{"variable": "{{webhook.name}}", "value": 1}`),
+				analysisNode("log", domain.NodeTypeLog, `{"message": "{{variables.anything}}"}`),
+			},
+			[]domain.Edge{analysisEdge("start", "set", ""), analysisEdge("set", "log", "")},
+			[]UndefinedVariable{},
+		},
+		{
+			"each variable reported once in key order",
+			[]domain.Node{
+				analysisNode("start", domain.NodeTypeStart, ""),
+				analysisNode("http", domain.NodeTypeHTTPRequest, `{"url": "https://example.com/{{variables.id}}", "headers": {"X-Id": "{{ id }}", "Authorization": "{{variables.token}}"}}`),
+			},
+			[]domain.Edge{analysisEdge("start", "http", "")},
+			[]UndefinedVariable{
+				{NodeID: "http", Variable: "token", Expression: "variables.token"},
+				{NodeID: "http", Variable: "id", Expression: "id"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := AnalyzeSchema(&domain.SchemaDefinition{Nodes: tt.nodes, Edges: tt.edges})
+			if !reflect.DeepEqual(analysis.UndefinedVariables, tt.want) {
+				t.Errorf("undefined variables = %+v, want %+v", analysis.UndefinedVariables, tt.want)
+			}
+		})
+	}
+}
@@ -1,14 +1,118 @@
 package executor
 
-// ExecutionContext - контекст выполнения схемы
-// Содержит все переменные, доступные внутри схемы
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/piplexa/algomap/internal/nodes"
+)
 
-// TODO: Реализовать в worker'е:
-// - webhook.payload.*
-// - user.email
-// - execution.id
+// ExecutionContext - контекст выполнения схемы
+// Содержит все переменные, доступные внутри схемы:
+// - webhook.payload.* - trigger_payload выполнения
+// - user.* - пользователь, запустивший выполнение (id, email, name)
+// - execution.* - id, schema_id, depth
 // - steps.<node_id>.output.*
 // - variables.*
-// - env.*
 //
-// Интерполяция переменных: {{path.to.variable}}
\ No newline at end of file
+// Переменные окружения (env.*) в контекст намеренно не попадают - схема не должна видеть секреты воркера.
+//
+// Интерполяция переменных: {{path.to.variable}}, см. nodes.ResolveReference
+
+// loadTriggerContext заполняет webhook и user в начальном контексте выполнения
+func (e *Engine) loadTriggerContext(ctx context.Context, tx *sql.Tx, state *ExecutionState) error {
+	var payloadJSON []byte
+	var triggerType string
+	var userID int64
+	var email string
+	var name sql.NullString
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT e.trigger_payload, t.name, u.id, u.email, u.name
+		FROM main.executions e
+		JOIN main.dict_trigger_type t ON t.id = e.id_trigger_type
+		JOIN main.users u ON u.id = e.created_by
+		WHERE e.id = $1
+	`, state.ExecutionID).Scan(&payloadJSON, &triggerType, &userID, &email, &name)
+	if err != nil {
+		return fmt.Errorf("failed to load execution trigger: %w", err)
+	}
+
+	var payload interface{}
+	if len(payloadJSON) > 0 {
+		if err := json.Unmarshal(payloadJSON, &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal trigger payload: %w", err)
+		}
+	}
+
+	state.Context.Webhook = map[string]interface{}{
+		"payload":      payload,
+		"trigger_type": triggerType,
+	}
+	state.Context.User = map[string]interface{}{
+		"id":    userID,
+		"email": email,
+		"name":  name.String,
+	}
+
+	return nil
+}
+
+// cloneContext глубокая копия контекста (через JSON - так же контекст хранится в БД)
+// Обработчик ноды получает копию, поэтому не уложившийся в таймаут обработчик
+// не может изменить контекст, который движок в это время сохраняет
+func cloneContext(execCtx *nodes.ExecutionContext) (*nodes.ExecutionContext, error) {
+	data, err := json.Marshal(execCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal context: %w", err)
+	}
+	clone := &nodes.ExecutionContext{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal context: %w", err)
+	}
+	return clone, nil
+}
+
+// mergeContext переносит в dst изменения, которые обработчик сделал в своей копии контекста:
+// before - копия до вызова обработчика, after - после. Меняются только изменённые ключи,
+// поэтому изменения соседних параллельных веток, сохранённые тем временем в dst, не теряются
+func mergeContext(dst, before, after *nodes.ExecutionContext) {
+	dst.Webhook = mergeMap(dst.Webhook, before.Webhook, after.Webhook)
+	dst.User = mergeMap(dst.User, before.User, after.User)
+	dst.Execution = mergeMap(dst.Execution, before.Execution, after.Execution)
+	dst.Steps = mergeMap(dst.Steps, before.Steps, after.Steps)
+	dst.Variables = mergeMap(dst.Variables, before.Variables, after.Variables)
+	dst.Loops = mergeMap(dst.Loops, before.Loops, after.Loops)
+
+	if before.Loop != after.Loop {
+		dst.Loop = after.Loop
+	}
+	if !reflect.DeepEqual(before.Item, after.Item) {
+		dst.Item = after.Item
+	}
+	if !reflect.DeepEqual(before.Index, after.Index) {
+		dst.Index = after.Index
+	}
+}
+
+// mergeMap применяет к dst добавленные, изменённые и удалённые ключи after относительно before
+func mergeMap[V any](dst, before, after map[string]V) map[string]V {
+	for key, value := range after {
+		if old, ok := before[key]; ok && reflect.DeepEqual(old, value) {
+			continue
+		}
+		if dst == nil {
+			dst = make(map[string]V)
+		}
+		dst[key] = value
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			delete(dst, key)
+		}
+	}
+	return dst
+}
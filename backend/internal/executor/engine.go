@@ -7,17 +7,30 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 
 	"github.com/piplexa/algomap/internal/domain"
 	"github.com/piplexa/algomap/internal/nodes"
 )
 
+// persistTimeout срок на работу с БД до и после выполнения ноды
+const persistTimeout = 30 * time.Second
+
 // Engine - движок выполнения схем
 type Engine struct {
 	db       *sql.DB
 	logger   *zap.Logger
 	registry *nodes.HandlerRegistry
+	limits   ExecutionLimits
+}
+
+// ExecutionLimits лимиты одного выполнения, 0 - без ограничения
+// Значения по умолчанию берутся из конфигурации воркера, переопределяются
+// настройками пользователя (main.users) и схемы (settings в definition)
+type ExecutionLimits struct {
+	MaxSteps    int64
+	MaxDuration time.Duration // от начала выполнения, включая ожидание sleep
 }
 
 // ExecutionMessage - сообщение из RabbitMQ
@@ -26,6 +39,16 @@ type ExecutionMessage struct {
 	SchemaID      int64  `json:"schema_id"`
 	CurrentNodeID string `json:"current_node_id"`
 	DebugMode     bool   `json:"debug_mode"`
+	// ChildExecutionID заполняется, когда дочерняя схема (sub_schema) завершилась
+	// и родитель продолжает выполнение с ноды, которая её вызвала
+	ChildExecutionID string `json:"child_execution_id,omitempty"`
+	// PrevNodeID нода, из которой пришли (нужна join ноде, чтобы понять какая ветка пришла)
+	PrevNodeID string `json:"prev_node_id,omitempty"`
+	// SkipBreakpoint нода отпущена отладчиком (step/continue) и выполняется без повторной остановки.
+	// В сообщения следующих нод не переносится
+	SkipBreakpoint bool `json:"skip_breakpoint,omitempty"`
+	// Branches стек веток parallel нод, в которых выполняется нода (по нему считает join)
+	Branches []BranchRef `json:"branches,omitempty"`
 }
 
 // ExecutionState - состояние выполнения
@@ -35,92 +58,181 @@ type ExecutionState struct {
 	Context          *nodes.ExecutionContext
 	UpdatedAt        time.Time
 	CntExecutedSteps int64
-}
 
-// SchemaDefinition - определение схемы
-type SchemaDefinition struct {
-	Nodes []nodes.Node `json:"nodes"`
-	Edges []Edge       `json:"edges"`
-}
+	// Связь с родительским выполнением (для sub_schema)
+	ParentExecutionID *string
+	ParentNodeID      *string
+	Depth             int
+
+	// Количество активных веток выполнения (parallel увеличивает, завершение ветки уменьшает)
+	ActiveBranches int
 
-// Edge - связь между нодами
-type Edge struct {
-	Source       string `json:"source"`
-	Target       string `json:"target"`
-	SourceHandle string `json:"sourceHandle,omitempty"`
+	// Стеки веток, уснувших в sleep или ждущих дочернюю схему, по ноде, с которой они продолжатся.
+	// Сообщение продолжения (callback sleep, завершение дочерней схемы) приходит без стека веток
+	SuspendedBranches map[string][][]BranchRef
 }
 
 // NewEngine создаёт новый движок
-func NewEngine(db *sql.DB, logger *zap.Logger, registry *nodes.HandlerRegistry) *Engine {
+func NewEngine(db *sql.DB, logger *zap.Logger, registry *nodes.HandlerRegistry, limits ExecutionLimits) *Engine {
 	return &Engine{
 		db:       db,
 		logger:   logger,
 		registry: registry,
+		limits:   limits,
 	}
 }
 
-// Execute выполняет одну ноду и возвращает ID следующей ноды (если есть)
-// +вернуть признак needContinue: нужно продолжать выполнение схемы или нет. Например при sleep не нужно.
+// Execute выполняет одну ноду и возвращает сообщения, которые нужно опубликовать в очередь
+// Обычно это одно сообщение со следующей нодой. Пусто - если продолжать не нужно (end, sleep, ожидание sub_schema).
+// Сообщения могут вернуться и вместе с ошибкой (например, продолжение родителя при падении дочерней схемы).
 // +добавить сохранения количества выполненных шагов в main.executions.cnt_executed_steps
-// TODO: Если количество выполнений шагов (main.executions.cnt_executed_steps) больше N - вернуть ошибку
-func (e *Engine) Execute(ctx context.Context, msg *ExecutionMessage) (*string, *bool, error) {
+// Перед выполнением ноды проверяются лимиты шагов и длительности (см. ExecutionLimits)
+func (e *Engine) Execute(ctx context.Context, msg *ExecutionMessage) ([]*ExecutionMessage, error) {
 	var needContinue bool = true
+	var messages []*ExecutionMessage
 
 	e.logger.Info("Выполнение ноды: ",
 		zap.String("execution_id", msg.ExecutionID),
 		zap.String("node_id", msg.CurrentNodeID),
 	)
 
-	// Создаём контекст с таймаутом для транзакции
-	execCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	// Контекст с таймаутом для работы с БД. Время выполнения самой ноды в него не входит:
+	// обработчик работает под своим таймаутом, а перед сохранением результата срок выдаётся заново
+	execCtx, cancel := context.WithTimeout(ctx, persistTimeout)
 	defer cancel()
 
-	// Начинаем транзакцию
+	// Работа с БД разбита на две транзакции: подготовка до вызова обработчика и сохранение результата после.
+	// Обработчик (HTTP, запросы к БД, ...) выполняется без открытой транзакции и без блокировки выполнения,
+	// поэтому параллельные ветки одного выполнения действительно выполняются параллельно
 	tx, err := e.db.BeginTx(execCtx, nil)
 	if err != nil {
-		return nil, &needContinue, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	// 0. Блокируем выполнение до конца транзакции подготовки
+	statusID, control, err := e.lockExecution(execCtx, tx, msg.ExecutionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock execution: %w", err)
+	}
+	if isFinalStatus(statusID) {
+		// Выполнение уже завершено или остановлено (например, упала соседняя параллельная ветка) - ничего не делаем
+		e.logger.Info("Выполнение уже завершено, сообщение пропущено",
+			zap.String("execution_id", msg.ExecutionID),
+			zap.String("node_id", msg.CurrentNodeID),
+			zap.Int16("status", statusID),
+		)
+		return nil, nil
+	}
+	if control == domain.ExecutionControlPause {
+		// Выполнение на паузе - откладываем ноду, resume опубликует её заново
+		if err := e.parkMessage(execCtx, tx, msg, domain.ParkReasonPause); err != nil {
+			return nil, fmt.Errorf("failed to park message: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		e.logger.Info("Выполнение на паузе, нода отложена",
+			zap.String("execution_id", msg.ExecutionID),
+			zap.String("node_id", msg.CurrentNodeID),
+		)
+		return nil, nil
+	}
+
 	// 1. Загружаем состояние выполнения (или создаём начальное)
 	state, err := e.loadExecutionState(execCtx, tx, msg.ExecutionID)
 	if err != nil {
-		return nil, &needContinue, fmt.Errorf("failed to load execution state: %w", err)
+		return nil, fmt.Errorf("failed to load execution state: %w", err)
 	}
 	if state == nil {
 		state = e.initializeState(msg)
-	}
-	// TODO: Число конечно нужно вынести в настройку пользователя.
-	// TODO: Чтобы у каждого пользователя была возможность ограничивать количество шагов в алгоритме
-	// TODO: Вся эта канитель нужна только для того, чтобы в вечные циклы не уходили и алгоритмы писали лучше
-	if state.CntExecutedSteps >= 100 {
-		needContinue = false // продолжать алгоритм не нужно
-		// Сохранить error
-		if err := e.updateExecutionError(msg.ExecutionID, "превышен лимит выполнения шагов в алгоритме"); err != nil {
-			return nil, &needContinue, fmt.Errorf("failed to save execution error step: %w", err)
+		if err := e.loadTriggerContext(execCtx, tx, state); err != nil {
+			return nil, err
 		}
-		return nil, &needContinue, fmt.Errorf("превышен лимит выполнения шагов в алгоритме: %d", state.CntExecutedSteps)
 	}
-
 	// 2. Загружаем схему
-	schema, err := e.loadSchema(execCtx, tx, msg.SchemaID)
+	schema, err := e.loadSchema(execCtx, tx, msg.ExecutionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+
+	// Лимиты нужны, чтобы вечные циклы не занимали воркеры
+	limitErr, err := e.checkLimits(execCtx, tx, msg, state, schema)
 	if err != nil {
-		return nil, &needContinue, fmt.Errorf("failed to load schema: %w", err)
+		return nil, fmt.Errorf("failed to check execution limits: %w", err)
+	}
+	if limitErr != nil {
+		return e.failExecution(execCtx, tx, state, msg, limitErr)
 	}
 
 	// 3. Находим ноду
 	node := e.findNode(schema, msg.CurrentNodeID)
 	if node == nil {
-		return nil, &needContinue, fmt.Errorf("node not found: %s", msg.CurrentNodeID)
+		// Схема не содержит ноду из сообщения - выполнение продолжить нельзя
+		return e.failExecution(execCtx, tx, state, msg, &domain.ExecutionError{
+			Code:    domain.ErrorCodeNodeNotFound,
+			Message: fmt.Sprintf("node not found: %s", msg.CurrentNodeID),
+			NodeID:  msg.CurrentNodeID,
+		})
+	}
+
+	// Режим отладки: останавливаемся перед нодой (step - перед каждой, иначе - на breakpoint)
+	// Продолжение после дочерней схемы не останавливается - нода уже начала выполняться
+	if msg.DebugMode && !msg.SkipBreakpoint && msg.ChildExecutionID == "" &&
+		(control == domain.ExecutionControlStep || node.Data.Breakpoint) {
+		reason := domain.ParkReasonBreakpoint
+		if control == domain.ExecutionControlStep {
+			reason = domain.ParkReasonStep
+		}
+		// Состояние сохраняем, чтобы переменные можно было посмотреть и изменить ещё до первой ноды
+		if err := e.saveExecutionState(execCtx, tx, state); err != nil {
+			return nil, fmt.Errorf("failed to save execution state: %w", err)
+		}
+		if err := e.parkMessage(execCtx, tx, msg, reason); err != nil {
+			return nil, fmt.Errorf("failed to park message: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		e.logger.Info("Отладка: выполнение остановлено перед нодой",
+			zap.String("execution_id", msg.ExecutionID),
+			zap.String("node_id", msg.CurrentNodeID),
+			zap.String("reason", reason),
+		)
+		return nil, nil
 	}
 
 	// 4. Получаем обработчик ноды (используем реальный тип из data.type)
 	handler, ok := e.registry.Get(node.Data.Type)
 	if !ok {
-		return nil, &needContinue, fmt.Errorf("handler not found for node type: %s", node.Data.Type)
+		return e.failExecution(execCtx, tx, state, msg, &domain.ExecutionError{
+			Code:    domain.ErrorCodeUnknownNodeType,
+			Message: fmt.Sprintf("handler not found for node type: %s", node.Data.Type),
+			Details: map[string]interface{}{"node_type": node.Data.Type},
+			NodeID:  msg.CurrentNodeID,
+		})
 	}
 
-	// 5. Выполняем ноду
+	// 5. Определяем предыдущую ноду
+	// Для первой ноды (Start) prevNodeID будет nil
+	var prevNodeID *string
+	if msg.PrevNodeID != "" {
+		prevNodeID = &msg.PrevNodeID
+	} else if state.CurrentNodeID != "" && state.CurrentNodeID != msg.CurrentNodeID {
+		prevNodeID = &state.CurrentNodeID
+	}
+
+	// Foreach, в который пришли не из его тела (прошлый проход прервался выходом error или условием),
+	// начинает цикл заново. Продолжение после sleep приходит без prev_node_id - прогресс сохраняется
+	resetLoop := false
+	if _, running := state.Context.Loops[msg.CurrentNodeID]; running && node.Data.Type == domain.NodeTypeForeach && msg.PrevNodeID != "" {
+		resetLoop = !fromLoopBody(schema, msg.CurrentNodeID, msg.PrevNodeID)
+	}
+	if resetLoop {
+		nodes.ResetLoop(state.Context, msg.CurrentNodeID)
+	}
+
+	// 6. Выполняем ноду
 	startedAt := time.Now()
 	e.logger.Log(-2, "Подготовка к выполнению ноды.",
 		zap.String("node_type", node.Data.Type),
@@ -129,8 +241,38 @@ func (e *Engine) Execute(ctx context.Context, msg *ExecutionMessage) (*string, *
 	var preNextNodeID *string
 	preNextNodeID = e.findNextNode(schema, msg.CurrentNodeID, "success")
 
-	result, err := handler.Execute(execCtx, node, state.Context, preNextNodeID)
-	finishedAt := time.Now()
+	var result *nodes.NodeResult
+	var changes *contextChanges
+	if msg.ChildExecutionID != "" {
+		// Дочерняя схема завершилась - забираем её результат вместо повторного вызова обработчика
+		result, err = e.collectChildResult(execCtx, tx, msg)
+	} else if config, cfgErr := domain.ValidateNodeConfig(node.Data.Type, node.Data.Config); cfgErr != nil {
+		// Конфигурация не соответствует схеме типа ноды - обработчик не вызываем
+		errMsg := cfgErr.Error()
+		result = &nodes.NodeResult{
+			Status:     nodes.StatusFailed,
+			Error:      &errMsg,
+			ErrorCode:  domain.ErrorCodeInvalidConfig,
+			ExitHandle: "error",
+		}
+	} else {
+		// Обработчик получает конфигурацию с подставленными значениями по умолчанию
+		node.Data.Config = config
+	}
+
+	// Подготовка закончена - отпускаем блокировку до вызова обработчика
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	cancel()
+
+	if result == nil && err == nil {
+		result, changes, err = e.executeWithTimeout(ctx, handler, node, state.Context, preNextNodeID)
+		if ctx.Err() != nil {
+			// Воркер останавливается - сообщение будет обработано заново
+			return nil, ctx.Err()
+		}
+	}
 
 	if err != nil {
 		// Сохраняем ошибку
@@ -140,36 +282,151 @@ func (e *Engine) Execute(ctx context.Context, msg *ExecutionMessage) (*string, *
 			Error:  &errMsg,
 		}
 	}
+
+	// Обработчик мог работать долго - сохранение получает свой срок
+	persistCtx, persistCancel := context.WithTimeout(ctx, persistTimeout)
+	defer persistCancel()
+	execCtx = persistCtx
+
+	// Транзакция сохранения результата: блокируем выполнение заново
+	tx, err = e.db.BeginTx(execCtx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statusID, _, err = e.lockExecution(execCtx, tx, msg.ExecutionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock execution: %w", err)
+	}
+	if isFinalStatus(statusID) {
+		// Пока нода выполнялась, выполнение остановили или оно упало в соседней ветке - результат не нужен
+		e.logger.Info("Выполнение завершено во время выполнения ноды, результат отброшен",
+			zap.String("execution_id", msg.ExecutionID),
+			zap.String("node_id", msg.CurrentNodeID),
+			zap.Int16("status", statusID),
+		)
+		return nil, nil
+	}
+
+	// Состояние перечитываем: пока нода выполнялась, его могли изменить параллельные ветки и отладчик.
+	// Изменения контекста, сделанные нодой, накладываются поверх
+	freshState, err := e.loadExecutionState(execCtx, tx, msg.ExecutionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load execution state: %w", err)
+	}
+	if freshState != nil {
+		state = freshState
+	}
+	if resetLoop {
+		nodes.ResetLoop(state.Context, msg.CurrentNodeID)
+	}
+	changes.applyTo(state.Context)
+
+	// Ветка продолжается после sleep (callback без prev_node_id) или дочерней схемы - восстанавливаем её стек веток
+	if len(msg.Branches) == 0 && (msg.PrevNodeID == "" || msg.ChildExecutionID != "") {
+		msg.Branches = state.resumeBranches(msg.CurrentNodeID)
+	}
+
+	// Нода запросила запуск дочерней схемы - создаём дочернее выполнение и ждём его завершения
+	if result.Status == nodes.StatusWaiting && result.SubSchema != nil {
+		childMsg, err := e.startChildExecution(execCtx, tx, msg, state, result.SubSchema)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to start sub schema: %v", err)
+			result = &nodes.NodeResult{
+				Output:     result.Output,
+				Status:     nodes.StatusFailed,
+				Error:      &errMsg,
+				ExitHandle: "error",
+			}
+		} else {
+			result.Output["child_execution_id"] = childMsg.ExecutionID
+			messages = append(messages, childMsg)
+			needContinue = false
+			// Родитель продолжится с этой же ноды
+			state.suspendBranches(msg.CurrentNodeID, msg.Branches)
+		}
+	}
 	// Если нода вернула статус sleep, то дальше не продолжаем выполнение схемы, о чем и сигнализируем в движок
 	if node.Data.Type == domain.NodeTypeSleep {
 		e.logger.Debug("Нода типа sleep - нет смысла продолжать работу схемы.")
 		needContinue = false
+		// Callback sleep продолжит выполнение со следующей ноды
+		if result.Status == nodes.StatusSleep && preNextNodeID != nil {
+			state.suspendBranches(*preNextNodeID, msg.Branches)
+		}
 	}
 
-	// 6. Определяем предыдущую ноду
-	// Для первой ноды (Start) prevNodeID будет nil
-	var prevNodeID *string
-	if state.CurrentNodeID != "" && state.CurrentNodeID != msg.CurrentNodeID {
-		prevNodeID = &state.CurrentNodeID
+	// Join нода: отмечаем пришедшую ветку, дальше идёт только ветка, которая пришла последней из нужных
+	joinWaiting := false
+	nextBranches := msg.Branches
+	if result.Join != nil && result.Status == nodes.StatusSuccess {
+		fired, err := e.arriveAtJoin(execCtx, sqlJoinStore{tx: tx}, msg, schema, state, prevNodeID, result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register join arrival: %w", err)
+		}
+		joinWaiting = !fired
+		if fired && len(nextBranches) > 0 {
+			// Ветки parallel сошлись - дальше идём в ветке, из которой parallel был запущен
+			nextBranches = nextBranches[:len(nextBranches)-1]
+		}
 	}
+	finishedAt := time.Now()
 
 	// 7. Обновляем контекст
 	e.updateContext(state.Context, msg.CurrentNodeID, result)
 
-	// 8. Определяем следующую ноду
-	var nextNodeID *string
-	if node.Data.Type != domain.NodeTypeEnd {
+	// 8. Определяем следующие ноды
+	var nextNodeIDs []string
+	switch {
+	case node.Data.Type == domain.NodeTypeEnd, node.Data.Type == domain.NodeTypeError, result.Status == nodes.StatusWaiting, joinWaiting:
+		// Ожидающая нода (sub_schema) ещё не завершена - следующую ноду определим после её завершения
+		// Join ждёт остальные ветки - эта ветка дальше не идёт
+		// Error нода всегда завершает выполнение, даже если из неё проведено ребро
+
+	case node.Data.Type == domain.NodeTypeParallel && result.Status == nodes.StatusSuccess:
+		// Parallel запускает все исходящие ветки
+		nextNodeIDs = e.findAllNextNodes(schema, msg.CurrentNodeID)
+		result.Output["branches"] = nextNodeIDs
+
+	default:
 		// Для failed статуса ищем error выход, для success - success выход
 		exitHandle := result.ExitHandle
 		if exitHandle == "" {
 			// Если обработчик не указал exitHandle, используем дефолтные
-			if result.Status == nodes.StatusFailed {
+			if isFailed(result) {
 				exitHandle = "error"
 			} else if result.Status == nodes.StatusSuccess {
 				exitHandle = "success"
 			}
 		}
-		nextNodeID = e.findNextNode(schema, msg.CurrentNodeID, exitHandle)
+		if next := e.findNextNode(schema, msg.CurrentNodeID, exitHandle); next != nil {
+			nextNodeIDs = []string{*next}
+		}
+	}
+
+	var nextNodeID *string
+	if len(nextNodeIDs) > 0 {
+		nextNodeID = &nextNodeIDs[0]
+	}
+
+	// Учёт активных веток: sleep и ожидание дочерней схемы ветку не завершают
+	suspended := result.Status == nodes.StatusSleep || result.Status == nodes.StatusWaiting
+	if !suspended {
+		state.ActiveBranches += len(nextNodeIDs) - 1
+	}
+
+	// Новый статус выполнения
+	newStatus := domain.ExecutionStatusRunning
+	switch {
+	case isFailed(result) && len(nextNodeIDs) == 0:
+		// Ошибка без error выхода - выполнение падает целиком (вместе с параллельными ветками)
+		newStatus = domain.ExecutionStatusFailed
+	case suspended && state.ActiveBranches <= 1:
+		newStatus = domain.ExecutionStatusPaused
+	case !suspended && state.ActiveBranches <= 0:
+		// Это была последняя активная ветка (End)
+		newStatus = domain.ExecutionStatusCompleted
 	}
 
 	// 9. Сохраняем обновлённое состояние
@@ -180,24 +437,46 @@ func (e *Engine) Execute(ctx context.Context, msg *ExecutionMessage) (*string, *
 	state.UpdatedAt = time.Now()
 
 	if err := e.saveExecutionState(execCtx, tx, state); err != nil {
-		return nil, &needContinue, fmt.Errorf("failed to save execution state: %w", err)
+		return nil, fmt.Errorf("failed to save execution state: %w", err)
 	}
 
 	// 10. Сохраняем шаг в execution_steps (теперь с prev_node_id и next_node_id)
 	if err := e.saveExecutionStep(execCtx, tx, msg.ExecutionID, node, result, prevNodeID, nextNodeID, startedAt, finishedAt, state); err != nil {
-		return nil, &needContinue, fmt.Errorf("failed to save execution step: %w", err)
+		return nil, fmt.Errorf("failed to save execution step: %w", err)
 	}
 
 	// 11. Обновляем статус execution
 	// +1 к количеству выполненных шагов
 	state.CntExecutedSteps = state.CntExecutedSteps + 1
-	if err := e.updateExecutionStatus(execCtx, tx, msg, result, nextNodeID, &state.CntExecutedSteps); err != nil {
-		return nil, &needContinue, fmt.Errorf("failed to update execution status: %w", err)
+	var execErr *domain.ExecutionError
+	if newStatus == domain.ExecutionStatusFailed {
+		execErr = executionError(msg.CurrentNodeID, result)
+	}
+	if err := e.updateExecutionStatus(execCtx, tx, msg, newStatus, execErr, &state.CntExecutedSteps); err != nil {
+		return nil, fmt.Errorf("failed to update execution status: %w", err)
+	}
+
+	// End нода с output - сохраняем итоговый результат выполнения
+	if node.Data.Type == domain.NodeTypeEnd && result.Output["output"] != nil {
+		if err := e.saveExecutionOutput(execCtx, tx, msg.ExecutionID, result.Output["output"]); err != nil {
+			return nil, fmt.Errorf("failed to save execution output: %w", err)
+		}
+	}
+
+	// 12. Если выполнение завершилось и это дочерняя схема - продолжаем родителя
+	if isFinalStatus(newStatus) {
+		parentMsg, err := e.parentResumeMessage(execCtx, tx, state, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build parent resume message: %w", err)
+		}
+		if parentMsg != nil {
+			messages = append(messages, parentMsg)
+		}
 	}
 
 	// Коммитим транзакцию
 	if err := tx.Commit(); err != nil {
-		return nil, &needContinue, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	e.logger.Info("Нода выполнена успешно: ",
@@ -206,8 +485,161 @@ func (e *Engine) Execute(ctx context.Context, msg *ExecutionMessage) (*string, *
 		zap.String("status", result.Status),
 	)
 
-	// Возвращаем ID следующей ноды (если есть)
-	return nextNodeID, &needContinue, nil
+	// Возвращаем сообщения со следующими нодами (если есть и если нужно продолжать)
+	if needContinue {
+		// Parallel открывает новый запуск: каждая ветка получает свой номер в нём
+		fork := ""
+		if node.Data.Type == domain.NodeTypeParallel && result.Status == nodes.StatusSuccess {
+			fork = uuid.New().String()
+		}
+		for i, id := range nextNodeIDs {
+			branches := nextBranches
+			if fork != "" {
+				branches = append(append([]BranchRef(nil), nextBranches...), BranchRef{Fork: fork, Index: i, Width: len(nextNodeIDs)})
+			}
+			messages = append(messages, &ExecutionMessage{
+				ExecutionID:   msg.ExecutionID,
+				SchemaID:      msg.SchemaID,
+				CurrentNodeID: id,
+				DebugMode:     msg.DebugMode,
+				PrevNodeID:    msg.CurrentNodeID,
+				Branches:      branches,
+			})
+		}
+	}
+
+	return messages, nil
+}
+
+// executeWithTimeout вызывает обработчик ноды под её таймаутом (data.timeout или таймаут типа)
+// Если обработчик не уложился, возвращается результат со статусом timeout и выходом error.
+// Обработчик, не следящий за ctx, продолжит работу в фоне, но его результат уже не используется
+func (e *Engine) executeWithTimeout(
+	ctx context.Context,
+	handler nodes.NodeHandler,
+	node *nodes.Node,
+	execCtx *nodes.ExecutionContext,
+	preNextNodeID *string,
+) (*nodes.NodeResult, *contextChanges, error) {
+	return runWithTimeout(ctx, e.logger, handler, node, execCtx, preNextNodeID)
+}
+
+// contextChanges изменения контекста, сделанные обработчиком ноды в своей копии
+type contextChanges struct {
+	before *nodes.ExecutionContext
+	after  *nodes.ExecutionContext
+}
+
+// applyTo переносит изменения в контекст выполнения (nil - переносить нечего)
+func (c *contextChanges) applyTo(dst *nodes.ExecutionContext) {
+	if c != nil {
+		mergeContext(dst, c.before, c.after)
+	}
+}
+
+// runWithTimeout вызывает обработчик под таймаутом ноды; не уложившаяся нода получает статус timeout
+// Обработчик работает с копией execCtx: сам execCtx не меняется, изменения копии возвращаются
+// только если нода выполнилась успешно (не упала, не паниковала и уложилась в таймаут)
+func runWithTimeout(
+	ctx context.Context,
+	logger *zap.Logger,
+	handler nodes.NodeHandler,
+	node *nodes.Node,
+	execCtx *nodes.ExecutionContext,
+	preNextNodeID *string,
+) (*nodes.NodeResult, *contextChanges, error) {
+	before, err := cloneContext(execCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	work, err := cloneContext(execCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeout := nodeTimeout(node)
+	handlerCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type handlerResult struct {
+		result   *nodes.NodeResult
+		err      error
+		panicked bool
+	}
+	done := make(chan handlerResult, 1)
+	go func() {
+		// Паника в обработчике - упавшая нода, а не упавший воркер
+		defer func() {
+			if p := recover(); p != nil {
+				logger.Error("Паника в обработчике ноды",
+					zap.String("node_id", node.ID),
+					zap.String("node_type", node.Data.Type),
+					zap.Any("panic", p),
+					zap.Stack("stack"),
+				)
+				errMsg := fmt.Sprintf("node handler panicked: %v", p)
+				done <- handlerResult{
+					result: &nodes.NodeResult{
+						Status:     nodes.StatusFailed,
+						Error:      &errMsg,
+						ExitHandle: "error",
+					},
+					panicked: true,
+				}
+			}
+		}()
+		result, err := handler.Execute(handlerCtx, node, work, preNextNodeID)
+		done <- handlerResult{result: result, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		// Обработчик мог вернуть ошибку из-за истёкшего ctx - это тоже таймаут
+		if handlerCtx.Err() != context.DeadlineExceeded || ctx.Err() != nil {
+			var changes *contextChanges
+			if res.err == nil && !res.panicked && res.result != nil && !isFailed(res.result) {
+				changes = &contextChanges{before: before, after: work}
+			}
+			return res.result, changes, res.err
+		}
+	case <-handlerCtx.Done():
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	logger.Warn("Нода не уложилась в таймаут",
+		zap.String("node_id", node.ID),
+		zap.String("node_type", node.Data.Type),
+		zap.Duration("timeout", timeout),
+	)
+	errMsg := fmt.Sprintf("node timed out after %s", timeout)
+	return &nodes.NodeResult{
+		Output: map[string]interface{}{
+			"timeout": int64(timeout / time.Second),
+		},
+		Status:     nodes.StatusTimeout,
+		Error:      &errMsg,
+		ErrorCode:  domain.ErrorCodeNodeTimeout,
+		ErrorData:  map[string]interface{}{"timeout": int64(timeout / time.Second)},
+		ExitHandle: "error",
+	}, nil, nil
+}
+
+// nodeTimeout таймаут ноды: из data.timeout, иначе таймаут типа ноды из реестра
+func nodeTimeout(node *nodes.Node) time.Duration {
+	if node.Data.Timeout > 0 {
+		return time.Duration(node.Data.Timeout) * time.Second
+	}
+	if schema, ok := domain.GetNodeTypeSchema(node.Data.Type); ok && schema.Timeout > 0 {
+		return time.Duration(schema.Timeout) * time.Second
+	}
+	return domain.DefaultNodeTimeout * time.Second
+}
+
+// isFailed - нода завершилась неуспешно (ошибка или таймаут)
+func isFailed(result *nodes.NodeResult) bool {
+	return result.Status == nodes.StatusFailed || result.Status == nodes.StatusTimeout
 }
 
 // initializeState создаёт начальное состояние
@@ -216,29 +648,32 @@ func (e *Engine) initializeState(msg *ExecutionMessage) *ExecutionState {
 		ExecutionID:   msg.ExecutionID,
 		CurrentNodeID: msg.CurrentNodeID,
 		Context: &nodes.ExecutionContext{
-			User: map[string]interface{}{
-				// TODO: загрузить из БД
-			},
+			User: map[string]interface{}{},
 			Execution: map[string]interface{}{
-				"id": msg.ExecutionID,
+				"id":        msg.ExecutionID,
+				"schema_id": msg.SchemaID,
+				"depth":     0,
 			},
 			Steps:     make(map[string]nodes.StepOutput),
 			Variables: make(map[string]interface{}),
 		},
-		UpdatedAt: time.Now(),
+		UpdatedAt:      time.Now(),
+		ActiveBranches: 1,
 	}
 }
 
 // loadExecutionState загружает состояние из БД
 func (e *Engine) loadExecutionState(ctx context.Context, tx *sql.Tx, executionID string) (*ExecutionState, error) {
 	var state ExecutionState
-	var contextJSON []byte
+	var contextJSON, branchesJSON []byte
 
 	err := tx.QueryRowContext(ctx, `
-		SELECT s.execution_id, s.current_node_id, s.context, s.updated_at, e.cnt_executed_steps
+		SELECT s.execution_id, s.current_node_id, s.context, s.updated_at, e.cnt_executed_steps,
+		       e.parent_execution_id, e.parent_node_id, e.depth, s.active_branches, s.suspended_branches
 		FROM main.execution_state s join main.executions e on s.execution_id = e.id
 		WHERE execution_id = $1
-	`, executionID).Scan(&state.ExecutionID, &state.CurrentNodeID, &contextJSON, &state.UpdatedAt, &state.CntExecutedSteps)
+	`, executionID).Scan(&state.ExecutionID, &state.CurrentNodeID, &contextJSON, &state.UpdatedAt, &state.CntExecutedSteps,
+		&state.ParentExecutionID, &state.ParentNodeID, &state.Depth, &state.ActiveBranches, &branchesJSON)
 
 	if err == sql.ErrNoRows {
 		return nil, nil // Первый запуск
@@ -250,22 +685,31 @@ func (e *Engine) loadExecutionState(ctx context.Context, tx *sql.Tx, executionID
 	if err := json.Unmarshal(contextJSON, &state.Context); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal context: %w", err)
 	}
+	if err := json.Unmarshal(branchesJSON, &state.SuspendedBranches); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal suspended branches: %w", err)
+	}
 
 	return &state, nil
 }
 
-// loadSchema загружает схему из БД
-func (e *Engine) loadSchema(ctx context.Context, tx *sql.Tx, schemaID int64) (*SchemaDefinition, error) {
+// loadSchema загружает definition той версии схемы, с которой запущено выполнение
+// Правки схемы во время выполнения (например, пока оно ждёт в sleep) на него не влияют.
+// Для выполнений без версии (созданных до версионирования) берётся текущий definition
+func (e *Engine) loadSchema(ctx context.Context, tx *sql.Tx, executionID string) (*domain.SchemaDefinition, error) {
 	var defJSON []byte
 	err := tx.QueryRowContext(ctx, `
-		SELECT definition FROM main.schemas WHERE id = $1
-	`, schemaID).Scan(&defJSON)
+		SELECT COALESCE(v.definition, s.definition)
+		FROM main.executions ex
+		JOIN main.schemas s ON s.id = ex.schema_id
+		LEFT JOIN main.schema_versions v ON v.schema_id = ex.schema_id AND v.version = ex.schema_version
+		WHERE ex.id = $1
+	`, executionID).Scan(&defJSON)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var schema SchemaDefinition
+	var schema domain.SchemaDefinition
 	if err := json.Unmarshal(defJSON, &schema); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal schema definition: %w", err)
 	}
@@ -274,10 +718,27 @@ func (e *Engine) loadSchema(ctx context.Context, tx *sql.Tx, schemaID int64) (*S
 }
 
 // findNode находит ноду в схеме
-func (e *Engine) findNode(schema *SchemaDefinition, nodeID string) *nodes.Node {
+func (e *Engine) findNode(schema *domain.SchemaDefinition, nodeID string) *nodes.Node {
+	return FindNode(schema, nodeID)
+}
+
+// FindNode находит ноду в definition и возвращает её копию в виде, который принимают обработчики нод
+// Тип ноды определяется так же, как при проверке схемы (domain.DefinitionNodeType)
+func FindNode(schema *domain.SchemaDefinition, nodeID string) *nodes.Node {
 	for i := range schema.Nodes {
 		if schema.Nodes[i].ID == nodeID {
-			return &schema.Nodes[i]
+			node := &schema.Nodes[i]
+			return &nodes.Node{
+				ID:   node.ID,
+				Type: node.Type,
+				Data: nodes.NodeData{
+					Type:       domain.DefinitionNodeType(node),
+					Label:      node.Data.Label,
+					Config:     node.Data.Config,
+					Timeout:    node.Data.Timeout,
+					Breakpoint: node.Data.Breakpoint,
+				},
+			}
 		}
 	}
 	return nil
@@ -305,9 +766,12 @@ func (e *Engine) saveExecutionStep(
 		return fmt.Errorf("failed to marshal context: %w", err)
 	}
 
-	status := int16(1) // success
-	if result.Status == nodes.StatusFailed {
-		status = 2
+	status := domain.StepStatusSuccess
+	switch result.Status {
+	case nodes.StatusFailed:
+		status = domain.StepStatusFailed
+	case nodes.StatusTimeout:
+		status = domain.StepStatusTimeout
 	}
 
 	_, err = tx.ExecContext(ctx, `
@@ -332,8 +796,26 @@ func (e *Engine) updateContext(ctx *nodes.ExecutionContext, nodeID string, resul
 	}
 }
 
+// findAllNextNodes возвращает все ноды, в которые ведут рёбра основного выхода текущей (для parallel)
+// Рёбра других выходов (error) веткой не считаются
+func (e *Engine) findAllNextNodes(schema *domain.SchemaDefinition, currentNodeID string) []string {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, edge := range schema.Edges {
+		if edge.Source != currentNodeID || seen[edge.Target] {
+			continue
+		}
+		if edge.SourceHandle != "" && edge.SourceHandle != "output" && edge.SourceHandle != "success" {
+			continue
+		}
+		seen[edge.Target] = true
+		targets = append(targets, edge.Target)
+	}
+	return targets
+}
+
 // findNextNode находит следующую ноду через edges с учётом exitHandle
-func (e *Engine) findNextNode(schema *SchemaDefinition, currentNodeID string, exitHandle string) *string {
+func (e *Engine) findNextNode(schema *domain.SchemaDefinition, currentNodeID string, exitHandle string) *string {
 	var defaultEdge *string
 
 	e.logger.Log(
@@ -413,70 +895,243 @@ func (e *Engine) saveExecutionState(ctx context.Context, tx *sql.Tx, state *Exec
 		return fmt.Errorf("failed to marshal context: %w", err)
 	}
 
+	branches := state.SuspendedBranches
+	if branches == nil {
+		branches = map[string][][]BranchRef{}
+	}
+	branchesJSON, err := json.Marshal(branches)
+	if err != nil {
+		return fmt.Errorf("failed to marshal suspended branches: %w", err)
+	}
+
 	_, err = tx.ExecContext(ctx, `
-		INSERT INTO main.execution_state (execution_id, current_node_id, context, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO main.execution_state (execution_id, current_node_id, context, updated_at, active_branches, suspended_branches)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (execution_id) DO UPDATE SET
 			current_node_id = EXCLUDED.current_node_id,
 			context = EXCLUDED.context,
-			updated_at = EXCLUDED.updated_at
-	`, state.ExecutionID, state.CurrentNodeID, contextJSON, state.UpdatedAt)
+			updated_at = EXCLUDED.updated_at,
+			active_branches = EXCLUDED.active_branches,
+			suspended_branches = EXCLUDED.suspended_branches
+	`, state.ExecutionID, state.CurrentNodeID, contextJSON, state.UpdatedAt, state.ActiveBranches, branchesJSON)
 
 	return err
 }
 
 // Сохранить ошибку выполнения в базе данных
-// + Отдельной транзакцией!
-func (e *Engine) updateExecutionError(ExecutionID string, errorMsg string) error {
-	_, err := e.db.Exec(`
+// Вызывается в транзакции Execute: строка выполнения уже заблокирована ею
+func (e *Engine) updateExecutionError(ctx context.Context, tx *sql.Tx, ExecutionID string, execErr *domain.ExecutionError) error {
+	errorJSON, err := json.Marshal(execErr)
+	if err != nil {
+		return fmt.Errorf("failed to marshal execution error: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
 		UPDATE main.executions
-		SET error = $2, id_status = 5
+		SET error = $2, id_status = 5, finished_at = NOW()
 		WHERE id = $1
-	`, ExecutionID, errorMsg)
+	`, ExecutionID, errorJSON)
 
 	return err
 }
 
+// checkLimits проверяет лимиты шагов и длительности выполнения
+// Возвращает ошибку выполнения, если лимит превышен. Заодно фиксирует started_at при первом шаге
+func (e *Engine) checkLimits(ctx context.Context, tx *sql.Tx, msg *ExecutionMessage, state *ExecutionState, schema *domain.SchemaDefinition) (*domain.ExecutionError, error) {
+	var elapsedSeconds float64
+	var userMaxSteps, userMaxDuration sql.NullInt64
+	err := tx.QueryRowContext(ctx, `
+		UPDATE main.executions e
+		SET started_at = COALESCE(e.started_at, NOW())
+		FROM main.users u
+		WHERE e.id = $1 AND u.id = e.created_by
+		RETURNING EXTRACT(EPOCH FROM (NOW() - e.started_at))::float8, u.max_steps, u.max_duration
+	`, msg.ExecutionID).Scan(&elapsedSeconds, &userMaxSteps, &userMaxDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	// Самая конкретная настройка важнее: схема > пользователь > конфигурация воркера
+	limits := e.limits
+	if userMaxSteps.Valid && userMaxSteps.Int64 > 0 {
+		limits.MaxSteps = userMaxSteps.Int64
+	}
+	if userMaxDuration.Valid && userMaxDuration.Int64 > 0 {
+		limits.MaxDuration = time.Duration(userMaxDuration.Int64) * time.Second
+	}
+	if schema.Settings.MaxSteps > 0 {
+		limits.MaxSteps = schema.Settings.MaxSteps
+	}
+	if schema.Settings.MaxDuration > 0 {
+		limits.MaxDuration = time.Duration(schema.Settings.MaxDuration) * time.Second
+	}
+
+	if limits.MaxSteps > 0 && state.CntExecutedSteps >= limits.MaxSteps {
+		return &domain.ExecutionError{
+			Code:    domain.ErrorCodeStepLimitExceeded,
+			Message: fmt.Sprintf("превышен лимит выполнения шагов в алгоритме: %d", limits.MaxSteps),
+			Details: map[string]interface{}{
+				"max_steps":      limits.MaxSteps,
+				"executed_steps": state.CntExecutedSteps,
+			},
+			NodeID: msg.CurrentNodeID,
+		}, nil
+	}
+
+	// В режиме отладки длительность не ограничивается: в неё вошло бы время остановок
+	elapsed := time.Duration(elapsedSeconds * float64(time.Second))
+	if limits.MaxDuration > 0 && !msg.DebugMode && elapsed >= limits.MaxDuration {
+		return &domain.ExecutionError{
+			Code:    domain.ErrorCodeTimeLimitExceeded,
+			Message: fmt.Sprintf("превышена максимальная длительность выполнения: %s", limits.MaxDuration),
+			Details: map[string]interface{}{
+				"max_duration":    int64(limits.MaxDuration / time.Second),
+				"elapsed_seconds": int64(elapsed / time.Second),
+			},
+			NodeID: msg.CurrentNodeID,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// failExecution завершает выполнение ошибкой до вызова обработчика ноды (лимиты, нода или её тип не найдены)
+// и коммитит транзакцию. Если выполнение дочернее - возвращает сообщение, которое продолжит родителя
+func (e *Engine) failExecution(ctx context.Context, tx *sql.Tx, state *ExecutionState, msg *ExecutionMessage, execErr *domain.ExecutionError) ([]*ExecutionMessage, error) {
+	var messages []*ExecutionMessage
+
+	if err := e.updateExecutionError(ctx, tx, msg.ExecutionID, execErr); err != nil {
+		return nil, fmt.Errorf("failed to save execution error step: %w", err)
+	}
+	// Если это дочерняя схема - родитель должен узнать о падении
+	if parentMsg, err := e.parentResumeMessage(ctx, tx, state, msg); err != nil {
+		e.logger.Error("failed to build parent resume message", zap.Error(err))
+	} else if parentMsg != nil {
+		messages = append(messages, parentMsg)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return messages, fmt.Errorf("%s: %s", execErr.Code, execErr.Message)
+}
+
+// executionError формирует ошибку выполнения по результату упавшей ноды
+func executionError(nodeID string, result *nodes.NodeResult) *domain.ExecutionError {
+	execErr := &domain.ExecutionError{
+		Code:    result.ErrorCode,
+		Details: result.ErrorData,
+		NodeID:  nodeID,
+	}
+	if execErr.Code == "" {
+		execErr.Code = domain.ErrorCodeNodeFailed
+	}
+	if result.Error != nil {
+		execErr.Message = *result.Error
+	}
+	return execErr
+}
+
+// saveExecutionOutput сохраняет итоговый результат выполнения (output end ноды)
+func (e *Engine) saveExecutionOutput(ctx context.Context, tx *sql.Tx, executionID string, output interface{}) error {
+	outputJSON, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE main.executions SET output = $2 WHERE id = $1
+	`, executionID, outputJSON)
+
+	return err
+}
+
+// lockExecution блокирует строку выполнения до конца транзакции и возвращает его статус и команду управления
+// API pause/resume/stop блокируют ту же строку. Pause применяется перед следующим шагом,
+// а stop во время выполнения ноды отбрасывает её результат (см. Execute)
+func (e *Engine) lockExecution(ctx context.Context, tx *sql.Tx, executionID string) (int16, string, error) {
+	var statusID int16
+	var control string
+	err := tx.QueryRowContext(ctx, `
+		SELECT id_status, COALESCE(control, '') FROM main.executions WHERE id = $1 FOR UPDATE
+	`, executionID).Scan(&statusID, &control)
+
+	return statusID, control, err
+}
+
+// parkMessage откладывает сообщение выполнения, стоящего на паузе или остановленного отладчиком,
+// в paused_nodes и записывает это в историю шагов (reason - pause, step, breakpoint)
+func (e *Engine) parkMessage(ctx context.Context, tx *sql.Tx, msg *ExecutionMessage, reason string) error {
+	msgJSON, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE main.executions
+		SET paused_nodes = paused_nodes || jsonb_build_array($2::jsonb), id_status = $3
+		WHERE id = $1
+	`, msg.ExecutionID, msgJSON, domain.ExecutionStatusPaused)
+	if err != nil {
+		return err
+	}
+
+	outputJSON, err := json.Marshal(map[string]interface{}{
+		"action": domain.ControlActionParked,
+		"reason": reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	var prevNodeID *string
+	if msg.PrevNodeID != "" {
+		prevNodeID = &msg.PrevNodeID
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO main.execution_steps (execution_id, node_id, node_type, prev_node_id, output, id_status, started_at, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+	`, msg.ExecutionID, msg.CurrentNodeID, domain.NodeTypeControl, prevNodeID, outputJSON, domain.StepStatusSkipped)
+
+	return err
+}
+
+// isFinalStatus - выполнение завершено и больше не продвигается
+func isFinalStatus(statusID int16) bool {
+	return statusID == domain.ExecutionStatusCompleted ||
+		statusID == domain.ExecutionStatusFailed ||
+		statusID == domain.ExecutionStatusStopped
+}
+
 // updateExecutionStatus обновляет статус execution
 func (e *Engine) updateExecutionStatus(
 	ctx context.Context,
 	tx *sql.Tx,
 	msg *ExecutionMessage,
-	result *nodes.NodeResult,
-	nextNodeID *string,
+	newStatus int16,
+	execErr *domain.ExecutionError,
 	cntExecutedSteps *int64,
 ) error {
-	// Определяем новый статус
-	var newStatus int16
 	var finishedAt *time.Time
-	var errorMsg *string
-
-	switch result.Status {
-	case nodes.StatusFailed:
-		newStatus = 5 // failed
+	if isFinalStatus(newStatus) {
 		now := time.Now()
 		finishedAt = &now
-		errorMsg = result.Error
-
-	case nodes.StatusSuccess:
-		if nextNodeID == nil {
-			// Это была последняя нода (End)
-			newStatus = 4 // completed
-			now := time.Now()
-			finishedAt = &now
-		} else {
-			newStatus = 2 // running
-		}
+	}
 
-	case nodes.StatusSleep:
-		newStatus = 3 // paused
+	var errorJSON []byte
+	if newStatus == domain.ExecutionStatusFailed && execErr != nil {
+		var err error
+		errorJSON, err = json.Marshal(execErr)
+		if err != nil {
+			return fmt.Errorf("failed to marshal execution error: %w", err)
+		}
 	}
 
 	_, err := tx.ExecContext(ctx, `
 		UPDATE main.executions
 		SET id_status = $1, current_step_id = $2, finished_at = $3, error = $4, cnt_executed_steps = $6
 		WHERE id = $5
-	`, newStatus, msg.CurrentNodeID, finishedAt, errorMsg, msg.ExecutionID, cntExecutedSteps)
+	`, newStatus, msg.CurrentNodeID, finishedAt, errorJSON, msg.ExecutionID, cntExecutedSteps)
 
 	return err
 }
@@ -0,0 +1,267 @@
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/piplexa/algomap/internal/domain"
+	"github.com/piplexa/algomap/internal/nodes"
+)
+
+// BranchRef ветка, запущенная parallel нодой
+// Сообщение несёт стек веток (вложенные parallel), последняя - та, в которой оно выполняется
+type BranchRef struct {
+	Fork  string `json:"fork"`  // запуск parallel ноды, уникален для каждого прохода через неё
+	Index int    `json:"index"` // номер ветки в запуске
+	Width int    `json:"width"` // сколько веток запущено
+}
+
+// currentBranch ветка, в которой выполняется сообщение (nil - вне parallel)
+func currentBranch(branches []BranchRef) *BranchRef {
+	if len(branches) == 0 {
+		return nil
+	}
+	return &branches[len(branches)-1]
+}
+
+// joinStore учёт веток, пришедших в join ноды
+type joinStore interface {
+	// isFired сработал ли join для запуска parallel fork
+	isFired(ctx context.Context, executionID, nodeID, fork string) (bool, error)
+	// arrive отмечает приход ветки, false - эта ветка уже отмечена
+	arrive(ctx context.Context, executionID, nodeID, fork, branch, sourceNodeID string) (bool, error)
+	// arrivals ноды, из которых пришли ветки, в порядке прихода
+	arrivals(ctx context.Context, executionID, nodeID, fork string) ([]string, error)
+	// clear удаляет приходы веток запуска fork
+	clear(ctx context.Context, executionID, nodeID, fork string) error
+	// markFired запоминает, что join для запуска fork сработал
+	markFired(ctx context.Context, executionID, nodeID, fork string) error
+}
+
+// arriveAtJoin регистрирует приход ветки в join ноду и возвращает true, если join сработал
+//
+// Ветки считаются по стеку веток сообщения: запуск parallel (fork) и номер ветки в нём.
+// Поэтому две ветки, пришедшие в join через одну и ту же ноду, - разные ветки, а ветки
+// следующего прохода через parallel (цикл, foreach) - новый запуск со своим отсчётом.
+// Join срабатывает на ветке, которая пришла N-й (по умолчанию - все ветки запуска), и запоминает это:
+// опоздавшие ветки того же запуска ничего не отмечают и просто завершаются.
+// Сообщение без веток (join не после parallel) считается по ноде, из которой пришло.
+//
+// Выполнение заблокировано (lockExecution), поэтому параллельные воркеры не гонятся.
+func (e *Engine) arriveAtJoin(
+	ctx context.Context,
+	store joinStore,
+	msg *ExecutionMessage,
+	schema *domain.SchemaDefinition,
+	state *ExecutionState,
+	prevNodeID *string,
+	result *nodes.NodeResult,
+) (bool, error) {
+	sourceNodeID := ""
+	if prevNodeID != nil {
+		sourceNodeID = *prevNodeID
+	}
+
+	// Какая ветка пришла и сколько веток нужно дождаться
+	fork, branchKey := "", sourceNodeID
+	required := result.Join.Required
+	if branch := currentBranch(msg.Branches); branch != nil {
+		fork = branch.Fork
+		branchKey = strconv.Itoa(branch.Index)
+		if required <= 0 {
+			required = branch.Width
+		}
+	}
+	if required <= 0 {
+		required = len(e.findIncomingNodes(schema, msg.CurrentNodeID))
+	}
+	if required <= 0 {
+		required = 1
+	}
+	result.Output["required"] = required
+
+	if fork != "" {
+		fired, err := store.isFired(ctx, msg.ExecutionID, msg.CurrentNodeID, fork)
+		if err != nil {
+			return false, err
+		}
+		if fired {
+			// Join этого запуска уже сработал (N из M) - ветка опоздала
+			result.Output["late"] = true
+			e.logger.Debug("Ветка пришла в уже сработавший join",
+				zap.String("execution_id", msg.ExecutionID),
+				zap.String("node_id", msg.CurrentNodeID),
+				zap.String("fork", fork),
+				zap.String("branch", branchKey),
+			)
+			return false, nil
+		}
+	}
+
+	inserted, err := store.arrive(ctx, msg.ExecutionID, msg.CurrentNodeID, fork, branchKey, sourceNodeID)
+	if err != nil {
+		return false, err
+	}
+	arrived, err := store.arrivals(ctx, msg.ExecutionID, msg.CurrentNodeID, fork)
+	if err != nil {
+		return false, err
+	}
+	result.Output["arrived"] = len(arrived)
+
+	fired := inserted && len(arrived) >= required
+	if !fired {
+		result.Output["waiting"] = len(arrived) < required
+		e.logger.Debug("Join ждёт остальные ветки",
+			zap.String("execution_id", msg.ExecutionID),
+			zap.String("node_id", msg.CurrentNodeID),
+			zap.String("fork", fork),
+			zap.String("branch", branchKey),
+			zap.Int("arrived", len(arrived)),
+			zap.Int("required", required),
+		)
+		return false, nil
+	}
+
+	// Сливаем результаты пришедших веток
+	branches := make(map[string]interface{}, len(arrived))
+	merged := make(map[string]interface{})
+	for _, id := range arrived[:required] {
+		step, ok := state.Context.Steps[id]
+		if !ok {
+			continue
+		}
+		branches[id] = step.Output
+		for k, v := range step.Output {
+			merged[k] = v
+		}
+	}
+	result.Output["branches"] = branches
+	result.Output["merged"] = merged
+
+	// Приходы больше не нужны: следующий проход через parallel - новый запуск.
+	// Без веток (join не после parallel) следующий приход начинает новый отсчёт
+	if err := store.clear(ctx, msg.ExecutionID, msg.CurrentNodeID, fork); err != nil {
+		return false, err
+	}
+	if fork != "" {
+		if err := store.markFired(ctx, msg.ExecutionID, msg.CurrentNodeID, fork); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// sqlJoinStore учёт веток в main.execution_join_arrivals и main.execution_join_fired
+type sqlJoinStore struct {
+	tx *sql.Tx
+}
+
+func (s sqlJoinStore) isFired(ctx context.Context, executionID, nodeID, fork string) (bool, error) {
+	var fired bool
+	err := s.tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM main.execution_join_fired
+			WHERE execution_id = $1 AND node_id = $2 AND fork = $3
+		)
+	`, executionID, nodeID, fork).Scan(&fired)
+	return fired, err
+}
+
+func (s sqlJoinStore) arrive(ctx context.Context, executionID, nodeID, fork, branch, sourceNodeID string) (bool, error) {
+	res, err := s.tx.ExecContext(ctx, `
+		INSERT INTO main.execution_join_arrivals (execution_id, node_id, fork, branch, source_node_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (execution_id, node_id, fork, branch) DO NOTHING
+	`, executionID, nodeID, fork, branch, sourceNodeID)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return inserted == 1, nil
+}
+
+func (s sqlJoinStore) arrivals(ctx context.Context, executionID, nodeID, fork string) ([]string, error) {
+	rows, err := s.tx.QueryContext(ctx, `
+		SELECT source_node_id FROM main.execution_join_arrivals
+		WHERE execution_id = $1 AND node_id = $2 AND fork = $3
+		ORDER BY arrived_at, branch
+	`, executionID, nodeID, fork)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var arrived []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		arrived = append(arrived, id)
+	}
+	return arrived, rows.Err()
+}
+
+func (s sqlJoinStore) clear(ctx context.Context, executionID, nodeID, fork string) error {
+	_, err := s.tx.ExecContext(ctx, `
+		DELETE FROM main.execution_join_arrivals
+		WHERE execution_id = $1 AND node_id = $2 AND fork = $3
+	`, executionID, nodeID, fork)
+	return err
+}
+
+func (s sqlJoinStore) markFired(ctx context.Context, executionID, nodeID, fork string) error {
+	_, err := s.tx.ExecContext(ctx, `
+		INSERT INTO main.execution_join_fired (execution_id, node_id, fork)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, executionID, nodeID, fork)
+	return err
+}
+
+// findIncomingNodes возвращает ноды, из которых есть рёбра в указанную
+func (e *Engine) findIncomingNodes(schema *domain.SchemaDefinition, nodeID string) []string {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, edge := range schema.Edges {
+		if edge.Target != nodeID || seen[edge.Source] {
+			continue
+		}
+		seen[edge.Source] = true
+		sources = append(sources, edge.Source)
+	}
+	return sources
+}
+
+// suspendBranches запоминает стек веток, который продолжится с ноды nodeID после sleep или дочерней схемы
+// На одну ноду могут уснуть несколько веток - стеки отдаются в порядке поступления
+func (s *ExecutionState) suspendBranches(nodeID string, branches []BranchRef) {
+	if len(branches) == 0 {
+		return
+	}
+	if s.SuspendedBranches == nil {
+		s.SuspendedBranches = make(map[string][][]BranchRef)
+	}
+	s.SuspendedBranches[nodeID] = append(s.SuspendedBranches[nodeID], branches)
+}
+
+// resumeBranches возвращает стек веток, продолжающийся с ноды nodeID (nil - ветка не засыпала в parallel)
+func (s *ExecutionState) resumeBranches(nodeID string) []BranchRef {
+	queue := s.SuspendedBranches[nodeID]
+	if len(queue) == 0 {
+		return nil
+	}
+	if len(queue) == 1 {
+		delete(s.SuspendedBranches, nodeID)
+	} else {
+		s.SuspendedBranches[nodeID] = queue[1:]
+	}
+	return queue[0]
+}
@@ -0,0 +1,200 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/piplexa/algomap/internal/domain"
+	"github.com/piplexa/algomap/internal/nodes"
+)
+
+// memJoinStore учёт веток join в памяти (одно выполнение, одна join нода)
+type memJoinStore struct {
+	arrived map[string][]joinArrival // по запуску parallel
+	fired   map[string]bool
+}
+
+type joinArrival struct {
+	branch string
+	source string
+}
+
+func newMemJoinStore() *memJoinStore {
+	return &memJoinStore{arrived: map[string][]joinArrival{}, fired: map[string]bool{}}
+}
+
+func (s *memJoinStore) isFired(_ context.Context, _, _, fork string) (bool, error) {
+	return s.fired[fork], nil
+}
+
+func (s *memJoinStore) arrive(_ context.Context, _, _, fork, branch, sourceNodeID string) (bool, error) {
+	for _, a := range s.arrived[fork] {
+		if a.branch == branch {
+			return false, nil
+		}
+	}
+	s.arrived[fork] = append(s.arrived[fork], joinArrival{branch: branch, source: sourceNodeID})
+	return true, nil
+}
+
+func (s *memJoinStore) arrivals(_ context.Context, _, _, fork string) ([]string, error) {
+	var sources []string
+	for _, a := range s.arrived[fork] {
+		sources = append(sources, a.source)
+	}
+	return sources, nil
+}
+
+func (s *memJoinStore) clear(_ context.Context, _, _, fork string) error {
+	delete(s.arrived, fork)
+	return nil
+}
+
+func (s *memJoinStore) markFired(_ context.Context, _, _, fork string) error {
+	s.fired[fork] = true
+	return nil
+}
+
+// joinArrive отправляет в join_1 ветку из source и возвращает, сработал ли join
+func joinArrive(t *testing.T, store joinStore, schema *domain.SchemaDefinition, source string, required int, branches ...BranchRef) (bool, *nodes.NodeResult) {
+	t.Helper()
+	engine := &Engine{logger: zap.NewNop()}
+	msg := &ExecutionMessage{ExecutionID: "exec-1", CurrentNodeID: "join_1", PrevNodeID: source, Branches: branches}
+	// Каждая входящая нода уже выполнилась и оставила свой output
+	state := &ExecutionState{Context: &nodes.ExecutionContext{Steps: map[string]nodes.StepOutput{}}}
+	for _, edge := range schema.Edges {
+		state.Context.Steps[edge.Source] = nodes.StepOutput{Output: map[string]interface{}{edge.Source: true}}
+	}
+	result := &nodes.NodeResult{
+		Output: map[string]interface{}{},
+		Status: nodes.StatusSuccess,
+		Join:   &nodes.JoinSpec{Required: required},
+	}
+
+	fired, err := engine.arriveAtJoin(context.Background(), store, msg, schema, state, &msg.PrevNodeID, result)
+	if err != nil {
+		t.Fatalf("arriveAtJoin error: %v", err)
+	}
+	return fired, result
+}
+
+func TestArriveAtJoinCountModeStraggler(t *testing.T) {
+	// parallel_1 -> a, b, c -> join_1 (count = 2), parallel_1 внутри цикла
+	schema := &domain.SchemaDefinition{Edges: []domain.Edge{
+		analysisEdge("a", "join_1", ""),
+		analysisEdge("b", "join_1", ""),
+		analysisEdge("c", "join_1", ""),
+	}}
+	store := newMemJoinStore()
+	branch := func(fork string, index int) BranchRef {
+		return BranchRef{Fork: fork, Index: index, Width: 3}
+	}
+
+	// Первый проход: срабатывает вторая ветка, третья опаздывает
+	if fired, _ := joinArrive(t, store, schema, "a", 2, branch("pass-1", 0)); fired {
+		t.Fatal("pass 1: join fired on the first branch")
+	}
+	fired, result := joinArrive(t, store, schema, "b", 2, branch("pass-1", 1))
+	if !fired {
+		t.Fatal("pass 1: join did not fire on the second branch")
+	}
+	if merged := result.Output["merged"].(map[string]interface{}); len(merged) != 2 || merged["a"] != true || merged["b"] != true {
+		t.Errorf("pass 1: merged = %v, want outputs of a and b", merged)
+	}
+	fired, result = joinArrive(t, store, schema, "c", 2, branch("pass-1", 2))
+	if fired {
+		t.Fatal("pass 1: straggler fired the join")
+	}
+	if result.Output["late"] != true {
+		t.Errorf("pass 1: straggler output = %v, want late", result.Output)
+	}
+	if len(store.arrived) != 0 {
+		t.Errorf("straggler left arrivals: %v", store.arrived)
+	}
+
+	// Второй проход: опоздавшая ветка первого прохода не засчитывается
+	fired, result = joinArrive(t, store, schema, "c", 2, branch("pass-2", 2))
+	if fired {
+		t.Fatal("pass 2: join fired on the first branch")
+	}
+	if result.Output["arrived"] != 1 {
+		t.Errorf("pass 2: arrived = %v, want 1", result.Output["arrived"])
+	}
+	if fired, _ := joinArrive(t, store, schema, "a", 2, branch("pass-2", 0)); !fired {
+		t.Fatal("pass 2: join did not fire on the second branch")
+	}
+}
+
+func TestArriveAtJoinBranchesThroughSameNode(t *testing.T) {
+	// parallel_1 -> a, b -> merge -> join_1: обе ветки приходят из merge
+	schema := &domain.SchemaDefinition{Edges: []domain.Edge{
+		analysisEdge("merge", "join_1", ""),
+	}}
+	store := newMemJoinStore()
+	outer := BranchRef{Fork: "outer", Index: 1, Width: 2}
+
+	fired, result := joinArrive(t, store, schema, "merge", 0, outer, BranchRef{Fork: "fork-1", Index: 0, Width: 2})
+	if fired {
+		t.Fatal("join fired on the first branch")
+	}
+	if result.Output["required"] != 2 {
+		t.Errorf("required = %v, want 2 (branches of the parallel run)", result.Output["required"])
+	}
+	if fired, _ := joinArrive(t, store, schema, "merge", 0, outer, BranchRef{Fork: "fork-1", Index: 1, Width: 2}); !fired {
+		t.Fatal("second branch through the same node did not fire the join")
+	}
+
+	// Повторная доставка сообщения той же ветки join не запускает
+	if fired, _ := joinArrive(t, store, schema, "merge", 0, outer, BranchRef{Fork: "fork-1", Index: 1, Width: 2}); fired {
+		t.Fatal("redelivered branch fired the join again")
+	}
+}
+
+func TestArriveAtJoinWithoutBranches(t *testing.T) {
+	// Ветки не из parallel считаются по нодам, из которых пришли, ждутся все входящие
+	schema := &domain.SchemaDefinition{Edges: []domain.Edge{
+		analysisEdge("a", "join_1", ""),
+		analysisEdge("b", "join_1", ""),
+	}}
+	store := newMemJoinStore()
+
+	for pass := 1; pass <= 2; pass++ {
+		if fired, _ := joinArrive(t, store, schema, "a", 0); fired {
+			t.Fatalf("pass %d: join fired on the first branch", pass)
+		}
+		if fired, _ := joinArrive(t, store, schema, "a", 0); fired {
+			t.Fatalf("pass %d: join fired on a repeated branch", pass)
+		}
+		if fired, _ := joinArrive(t, store, schema, "b", 0); !fired {
+			t.Fatalf("pass %d: join did not fire", pass)
+		}
+	}
+	if len(store.fired) != 0 {
+		t.Errorf("fired = %v, want nothing recorded without parallel", store.fired)
+	}
+}
+
+func TestSuspendedBranches(t *testing.T) {
+	state := &ExecutionState{}
+	first := []BranchRef{{Fork: "f", Index: 0, Width: 2}}
+	second := []BranchRef{{Fork: "f", Index: 1, Width: 2}}
+
+	state.suspendBranches("after_sleep", nil)
+	state.suspendBranches("after_sleep", first)
+	state.suspendBranches("after_sleep", second)
+
+	if got := state.resumeBranches("other"); got != nil {
+		t.Errorf("resumeBranches(other) = %v, want nil", got)
+	}
+	if got := state.resumeBranches("after_sleep"); len(got) != 1 || got[0] != first[0] {
+		t.Errorf("first resume = %v, want %v", got, first)
+	}
+	if got := state.resumeBranches("after_sleep"); len(got) != 1 || got[0] != second[0] {
+		t.Errorf("second resume = %v, want %v", got, second)
+	}
+	if len(state.SuspendedBranches) != 0 {
+		t.Errorf("SuspendedBranches = %v, want empty", state.SuspendedBranches)
+	}
+}
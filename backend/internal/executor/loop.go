@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"github.com/piplexa/algomap/internal/domain"
+	"github.com/piplexa/algomap/internal/nodes"
+)
+
+// fromLoopBody пришла ли ветка в foreach ноду loopID из её тела - по ребру, замыкающему итерацию
+//
+// Тело цикла - ноды, достижимые из выхода body, до которых от start нельзя дойти в обход foreach.
+// Выход из тела через error или условие ведёт за пределы цикла: если оттуда (или из внешнего цикла)
+// ветка снова придёт в foreach, это новый заход, и прогресс прошлого прохода нужно сбросить
+func fromLoopBody(schema *domain.SchemaDefinition, loopID, prevNodeID string) bool {
+	if prevNodeID == loopID {
+		return true
+	}
+	g := newSchemaGraph(schema)
+
+	// Рёбра графа без foreach ноды: пути, не проходящие через неё
+	links := make(map[string][]string, len(g.outgoing))
+	for id, targets := range g.outgoing {
+		if id == loopID {
+			continue
+		}
+		for _, target := range targets {
+			if target != loopID {
+				links[id] = append(links[id], target)
+			}
+		}
+	}
+
+	var body []string
+	for _, edge := range g.edges[loopID] {
+		if edge.SourceHandle == nodes.ForeachBodyHandle && edge.Target != loopID {
+			body = append(body, edge.Target)
+		}
+	}
+	if !g.reachable(body, links)[prevNodeID] {
+		return false
+	}
+
+	var starts []string
+	for _, id := range g.nodesOfType(domain.NodeTypeStart) {
+		if id != loopID {
+			starts = append(starts, id)
+		}
+	}
+	return !g.reachable(starts, links)[prevNodeID]
+}
@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/piplexa/algomap/internal/domain"
+)
+
+func TestFromLoopBody(t *testing.T) {
+	// start -> outer (foreach) -body-> inner (foreach) -body-> http -> check (condition)
+	//   check -true-> inner (итерация), check -false-> outer (выход из внутреннего цикла условием)
+	//   http -error-> skip -> inner (пропуск элемента)
+	//   inner -done-> outer, outer -done-> retry -> prepare -> outer (повторный заход после цикла)
+	schema := &domain.SchemaDefinition{
+		Nodes: []domain.Node{
+			analysisNode("start", domain.NodeTypeStart, ""),
+			analysisNode("prepare", domain.NodeTypeVariableSet, ""),
+			analysisNode("outer", domain.NodeTypeForeach, ""),
+			analysisNode("inner", domain.NodeTypeForeach, ""),
+			analysisNode("http", domain.NodeTypeHTTPRequest, ""),
+			analysisNode("check", domain.NodeTypeCondition, ""),
+			analysisNode("skip", domain.NodeTypeLog, ""),
+			analysisNode("retry", domain.NodeTypeCondition, ""),
+		},
+		Edges: []domain.Edge{
+			analysisEdge("start", "prepare", ""),
+			analysisEdge("prepare", "outer", ""),
+			analysisEdge("outer", "inner", "body"),
+			analysisEdge("inner", "http", "body"),
+			analysisEdge("http", "check", "success"),
+			analysisEdge("http", "skip", "error"),
+			analysisEdge("skip", "inner", ""),
+			analysisEdge("check", "inner", "true"),
+			analysisEdge("check", "outer", "false"),
+			analysisEdge("inner", "outer", "done"),
+			analysisEdge("outer", "retry", "done"),
+			analysisEdge("retry", "prepare", "true"),
+		},
+	}
+
+	tests := []struct {
+		name string
+		loop string
+		prev string
+		want bool
+	}{
+		{"next iteration of the inner loop", "inner", "check", true},
+		{"error handler inside the body", "inner", "skip", true},
+		{"entry into the inner loop from the outer one", "inner", "outer", false},
+		{"next iteration of the outer loop after break", "outer", "check", true},
+		{"next iteration of the outer loop after done", "outer", "inner", true},
+		{"entry into the outer loop", "outer", "prepare", false},
+		{"entry into the outer loop after retry", "outer", "retry", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fromLoopBody(schema, tt.loop, tt.prev); got != tt.want {
+				t.Errorf("fromLoopBody(%s, %s) = %v, want %v", tt.loop, tt.prev, got, tt.want)
+			}
+		})
+	}
+}
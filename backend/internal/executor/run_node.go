@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/piplexa/algomap/internal/domain"
+	"github.com/piplexa/algomap/internal/nodes"
+	"go.uber.org/zap"
+)
+
+// ErrNodeNotRunnable тип ноды нельзя выполнить отдельно от схемы
+// (ноды управления потоком - sleep, sub_schema, parallel, join, foreach - имеют смысл только внутри выполнения)
+var ErrNodeNotRunnable = errors.New("node type cannot be run in isolation")
+
+// NodeRunner выполняет одну ноду отдельно от выполнения схемы
+// Ничего не сохраняет: ни шагов, ни состояния - только возвращает результат обработчика.
+// Используется API, чтобы проверить ноду при построении схемы
+type NodeRunner struct {
+	registry *nodes.HandlerRegistry
+	logger   *zap.Logger
+}
+
+// NewNodeRunner создаёт NodeRunner с реестром обработчиков, которые можно вызывать отдельно
+func NewNodeRunner(registry *nodes.HandlerRegistry, logger *zap.Logger) *NodeRunner {
+	return &NodeRunner{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// Run выполняет ноду с переданным контекстом так же, как движок: проверка конфигурации,
+// значения по умолчанию, таймаут ноды. Изменения контекста успешной ноды переносятся в execCtx
+func (r *NodeRunner) Run(ctx context.Context, node *nodes.Node, execCtx *nodes.ExecutionContext) (*nodes.NodeResult, error) {
+	handler, ok := r.registry.Get(node.Data.Type)
+	if !ok {
+		return nil, ErrNodeNotRunnable
+	}
+
+	// Обработчики пишут в эти map - в переданном клиентом контексте их может не быть
+	if execCtx.User == nil {
+		execCtx.User = map[string]interface{}{}
+	}
+	if execCtx.Execution == nil {
+		execCtx.Execution = map[string]interface{}{}
+	}
+	if execCtx.Steps == nil {
+		execCtx.Steps = make(map[string]nodes.StepOutput)
+	}
+	if execCtx.Variables == nil {
+		execCtx.Variables = make(map[string]interface{})
+	}
+
+	config, err := domain.ValidateNodeConfig(node.Data.Type, node.Data.Config)
+	if err != nil {
+		errMsg := err.Error()
+		return &nodes.NodeResult{
+			Status:     nodes.StatusFailed,
+			Error:      &errMsg,
+			ErrorCode:  domain.ErrorCodeInvalidConfig,
+			ExitHandle: "error",
+		}, nil
+	}
+	node.Data.Config = config
+
+	r.logger.Info("Выполнение отдельной ноды",
+		zap.String("node_id", node.ID),
+		zap.String("node_type", node.Data.Type),
+	)
+
+	result, changes, err := runWithTimeout(ctx, r.logger, handler, node, execCtx, nil)
+	changes.applyTo(execCtx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Как и в движке, ошибка обработчика - это упавшая нода
+		errMsg := err.Error()
+		result = &nodes.NodeResult{
+			Status:     nodes.StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,229 @@
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/piplexa/algomap/internal/domain"
+	"github.com/piplexa/algomap/internal/nodes"
+)
+
+// Вызов дочерних схем (sub_schema)
+//
+// 1. Родитель выполняет sub_schema ноду -> обработчик возвращает StatusWaiting + SubSchemaCall
+// 2. Движок создаёт дочернее выполнение (main.executions с parent_execution_id) и публикует его start ноду
+// 3. Родитель остаётся на sub_schema ноде в статусе paused, воркер не блокируется
+// 4. Когда дочернее выполнение завершается (успешно или с ошибкой) - движок публикует сообщение
+//    родителю на ту же ноду с child_execution_id
+// 5. Родитель забирает результат дочерней схемы и идёт по выходу success или error
+
+// startChildExecution создаёт дочернее выполнение и возвращает сообщение для запуска его start ноды
+func (e *Engine) startChildExecution(
+	ctx context.Context,
+	tx *sql.Tx,
+	msg *ExecutionMessage,
+	parentState *ExecutionState,
+	call *nodes.SubSchemaCall,
+) (*ExecutionMessage, error) {
+	// Загружаем дочернюю схему. Вызывать можно только активные схемы того же владельца
+	var defJSON []byte
+	var schemaVersion int
+	err := tx.QueryRowContext(ctx, `
+		SELECT s.definition, s.version
+		FROM main.schemas s
+		JOIN main.executions p ON p.created_by = s.created_by
+		WHERE s.id = $1 AND s.id_status = $2 AND p.id = $3
+	`, call.SchemaID, domain.SchemaStatusActive, msg.ExecutionID).Scan(&defJSON, &schemaVersion)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("schema %d not found or not active", call.SchemaID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sub schema: %w", err)
+	}
+
+	var schema domain.SchemaDefinition
+	if err := json.Unmarshal(defJSON, &schema); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sub schema definition: %w", err)
+	}
+
+	startNodeID := ""
+	for _, node := range schema.Nodes {
+		if domain.DefinitionNodeType(&node) == domain.NodeTypeStart {
+			startNodeID = node.ID
+			break
+		}
+	}
+	if startNodeID == "" {
+		return nil, fmt.Errorf("sub schema %d has no start node", call.SchemaID)
+	}
+
+	payloadJSON, err := json.Marshal(call.Input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sub schema input: %w", err)
+	}
+
+	childID := uuid.New().String()
+	depth := parentState.Depth + 1
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO main.executions (
+			id, schema_id, schema_version, id_status, id_trigger_type, trigger_payload,
+			created_by, created_at, parent_execution_id, parent_node_id, depth,
+			debug_mode, control
+		)
+		SELECT $1, $2, $3, $4, $5, $6, p.created_by, $7, p.id, $8, $9,
+			p.debug_mode, CASE WHEN p.control = $11 THEN p.control END
+		FROM main.executions p
+		WHERE p.id = $10
+	`, childID, call.SchemaID, schemaVersion, domain.ExecutionStatusPending, domain.TriggerTypeSubSchema, payloadJSON,
+		time.Now().UTC(), msg.CurrentNodeID, depth, msg.ExecutionID, domain.ExecutionControlStep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create child execution: %w", err)
+	}
+
+	// Начальное состояние дочерней схемы: input_mapping становится её переменными
+	variables := call.Input
+	if variables == nil {
+		variables = make(map[string]interface{})
+	}
+	childState := &ExecutionState{
+		ExecutionID:   childID,
+		CurrentNodeID: startNodeID,
+		Context: &nodes.ExecutionContext{
+			User: parentState.Context.User,
+			Webhook: map[string]interface{}{
+				"payload":      call.Input,
+				"trigger_type": "sub_schema",
+			},
+			Execution: map[string]interface{}{
+				"id":        childID,
+				"schema_id": call.SchemaID,
+				"parent_id": msg.ExecutionID,
+				"depth":     depth,
+			},
+			Steps:     make(map[string]nodes.StepOutput),
+			Variables: variables,
+		},
+		UpdatedAt:      time.Now(),
+		ActiveBranches: 1,
+	}
+	if err := e.saveExecutionState(ctx, tx, childState); err != nil {
+		return nil, fmt.Errorf("failed to save child execution state: %w", err)
+	}
+
+	e.logger.Info("Запущена дочерняя схема",
+		zap.String("execution_id", msg.ExecutionID),
+		zap.String("child_execution_id", childID),
+		zap.Int64("schema_id", call.SchemaID),
+		zap.Int("depth", depth),
+	)
+
+	return &ExecutionMessage{
+		ExecutionID:   childID,
+		SchemaID:      call.SchemaID,
+		CurrentNodeID: startNodeID,
+		DebugMode:     msg.DebugMode,
+	}, nil
+}
+
+// collectChildResult формирует результат sub_schema ноды по завершившемуся дочернему выполнению
+func (e *Engine) collectChildResult(ctx context.Context, tx *sql.Tx, msg *ExecutionMessage) (*nodes.NodeResult, error) {
+	var statusID int16
+	var errorJSON []byte
+	var outputJSON []byte
+	var contextJSON []byte
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT e.id_status, e.error, e.output, COALESCE(s.context, '{}'::jsonb)
+		FROM main.executions e
+		LEFT JOIN main.execution_state s ON s.execution_id = e.id
+		WHERE e.id = $1 AND e.parent_execution_id = $2 AND e.parent_node_id = $3
+	`, msg.ChildExecutionID, msg.ExecutionID, msg.CurrentNodeID).Scan(&statusID, &errorJSON, &outputJSON, &contextJSON)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("child execution %s not found for node %s", msg.ChildExecutionID, msg.CurrentNodeID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load child execution: %w", err)
+	}
+
+	var childCtx nodes.ExecutionContext
+	if err := json.Unmarshal(contextJSON, &childCtx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal child context: %w", err)
+	}
+
+	// Итоговый результат дочерней схемы (output её end ноды) - возвращаемое значение вызова
+	var childOutput interface{}
+	if len(outputJSON) > 0 {
+		if err := json.Unmarshal(outputJSON, &childOutput); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal child output: %w", err)
+		}
+	}
+
+	output := map[string]interface{}{
+		"child_execution_id": msg.ChildExecutionID,
+		"variables":          childCtx.Variables,
+		"output":             childOutput,
+	}
+
+	if statusID != domain.ExecutionStatusCompleted {
+		// Ошибка дочерней схемы поднимается в родителя с тем же кодом
+		// Так бизнес-ошибка (error нода) не теряется при вызове через sub_schema
+		errMsg := "sub schema failed"
+		result := &nodes.NodeResult{
+			Output:     output,
+			Status:     nodes.StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}
+		var childErr domain.ExecutionError
+		if len(errorJSON) > 0 && json.Unmarshal(errorJSON, &childErr) == nil {
+			errMsg = fmt.Sprintf("sub schema failed: %s", childErr.Message)
+			result.ErrorCode = childErr.Code
+			result.ErrorData = childErr.Details
+			output["error"] = map[string]interface{}{
+				"code":    childErr.Code,
+				"message": childErr.Message,
+				"details": childErr.Details,
+				"node_id": childErr.NodeID,
+			}
+		}
+		output["status"] = "failed"
+		return result, nil
+	}
+
+	output["status"] = "completed"
+	return &nodes.NodeResult{
+		Output:     output,
+		Status:     nodes.StatusSuccess,
+		ExitHandle: "success",
+	}, nil
+}
+
+// parentResumeMessage возвращает сообщение для продолжения родителя, если выполнение - дочернее
+func (e *Engine) parentResumeMessage(ctx context.Context, tx *sql.Tx, state *ExecutionState, msg *ExecutionMessage) (*ExecutionMessage, error) {
+	if state.ParentExecutionID == nil || state.ParentNodeID == nil {
+		return nil, nil
+	}
+
+	var parentSchemaID int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT schema_id FROM main.executions WHERE id = $1
+	`, *state.ParentExecutionID).Scan(&parentSchemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExecutionMessage{
+		ExecutionID:      *state.ParentExecutionID,
+		SchemaID:         parentSchemaID,
+		CurrentNodeID:    *state.ParentNodeID,
+		DebugMode:        msg.DebugMode,
+		ChildExecutionID: msg.ExecutionID,
+	}, nil
+}
@@ -8,23 +8,36 @@ package handlers
 // GET    /api/executions/:id            	- статус выполнения
 // POST   /api/executions/:id-execution/:id-node/continue  - начать выполнение с указанного узла схемы
 // GET	  /api/executions/list/:id-schema	- список выполнений с фильтрацией по схеме
-
-// TODO: Реализовать endpoints:
 // POST   /api/executions/:id/pause      	- пауза
 // POST   /api/executions/:id/resume     	- продолжить
 // POST   /api/executions/:id/stop       	- остановить
+// POST   /api/executions/:id/debug/step     	- отладка: выполнить следующую ноду
+// POST   /api/executions/:id/debug/continue 	- отладка: выполнять до следующего breakpoint
+// GET    /api/executions/:id/debug/variables	- отладка: переменные выполнения
+// PUT    /api/executions/:id/debug/variables	- отладка: изменить переменные
+// POST   /api/executions/:id/debug/abort    	- отладка: прервать выполнение
+// POST   /api/executions/:id/retry      	- перезапустить упавшее выполнение с упавшего узла
+// POST   /api/executions/:id/steps/:stepId/fork - новое выполнение из шага истории
+// POST   /api/executions/:id/:id-node/one   	- выполнить только указанный узел схемы
+// POST   /api/schemas/:id/nodes/:id-node/run	- выполнить узел текущей версии схемы
+
+// TODO: Реализовать endpoints:
 // GET    /api/executions/:id/logs       	- логи выполнения
-// POST   /api/executions/:id/:id/one    	- выполнить только указанный узел схемы
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/piplexa/algomap/internal/domain"
+	"github.com/piplexa/algomap/internal/executor"
 	"github.com/piplexa/algomap/internal/middleware"
+	"github.com/piplexa/algomap/internal/nodes"
 	"github.com/piplexa/algomap/internal/repository"
 	"go.uber.org/zap"
 
@@ -43,6 +56,7 @@ type ExecutionHandler struct {
 	logger       *zap.Logger
 	rmqPublisher RabbitMQPublisher
 	queueName    string
+	nodeRunner   *executor.NodeRunner
 }
 
 // NewExecutionHandler создаёт новый handler для executions
@@ -52,6 +66,7 @@ func NewExecutionHandler(
 	logger *zap.Logger,
 	rmqPublisher RabbitMQPublisher,
 	queueName string,
+	nodeRunner *executor.NodeRunner,
 ) *ExecutionHandler {
 	return &ExecutionHandler{
 		execRepo:     execRepo,
@@ -59,6 +74,7 @@ func NewExecutionHandler(
 		logger:       logger,
 		rmqPublisher: rmqPublisher,
 		queueName:    queueName,
+		nodeRunner:   nodeRunner,
 	}
 }
 
@@ -143,8 +159,9 @@ func (h *ExecutionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Создаём execution в БД
-	execution, err := h.execRepo.Create(r.Context(), &req, userID)
+	// Создаём execution в БД: выполнение закрепляется за текущей версией схемы,
+	// последующие правки схемы его не затронут
+	execution, err := h.execRepo.Create(r.Context(), &req, userID, schema.Version)
 	if err != nil {
 		h.logger.Error("Failed to create execution",
 			zap.Error(err),
@@ -168,7 +185,10 @@ func (h *ExecutionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	)
 
 	if err := h.rmqPublisher.Publish(r.Context(), h.queueName, message); err != nil {
-		h.execRepo.UpdateStatus(r.Context(), execution.ID, 5, "Failed to publish to RabbitMQ")
+		h.execRepo.UpdateStatus(r.Context(), execution.ID, 5, &domain.ExecutionError{
+			Code:    domain.ErrorCodePublishFailed,
+			Message: "Failed to publish to RabbitMQ",
+		})
 		h.logger.Error("Failed to publish to RabbitMQ",
 			zap.Error(err),
 			zap.String("execution_id", execution.ID),
@@ -326,6 +346,524 @@ func (h *ExecutionHandler) Continue(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Pause ставит выполнение на паузу: текущая нода завершится, следующие будут отложены до resume
+// POST /api/executions/:id/pause
+func (h *ExecutionHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if err := h.execRepo.Pause(r.Context(), executionID, userID); err != nil {
+		h.respondControlError(w, executionID, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{
+		"message":      "Execution paused",
+		"execution_id": executionID,
+	})
+}
+
+// Resume снимает паузу и заново публикует отложенные ноды
+// POST /api/executions/:id/resume
+func (h *ExecutionHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	messages, err := h.execRepo.Resume(r.Context(), executionID, userID)
+	if err != nil {
+		h.respondControlError(w, executionID, err)
+		return
+	}
+
+	for _, message := range messages {
+		if err := h.rmqPublisher.Publish(r.Context(), h.queueName, message); err != nil {
+			h.execRepo.UpdateStatus(r.Context(), executionID, domain.ExecutionStatusFailed, &domain.ExecutionError{
+				Code:    domain.ErrorCodePublishFailed,
+				Message: "Failed to publish to RabbitMQ",
+			})
+			h.logger.Error("Failed to publish resumed node to RabbitMQ",
+				zap.Error(err),
+				zap.String("execution_id", executionID),
+			)
+			h.respondError(w, http.StatusInternalServerError, "Failed to queue execution")
+			return
+		}
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":      "Execution resumed",
+		"execution_id": executionID,
+		"resumed":      len(messages),
+	})
+}
+
+// Stop останавливает выполнение (вместе с дочерними выполнениями sub_schema)
+// Если остановлено дочернее выполнение - родитель продолжается по error выходу sub_schema ноды
+// POST /api/executions/:id/stop
+func (h *ExecutionHandler) Stop(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	execution, err := h.execRepo.Stop(r.Context(), executionID, userID)
+	if err != nil {
+		h.respondControlError(w, executionID, err)
+		return
+	}
+
+	if execution.ParentExecutionID != nil && execution.ParentNodeID != nil {
+		parent, err := h.execRepo.GetByID(r.Context(), *execution.ParentExecutionID)
+		if err != nil {
+			h.logger.Error("Failed to get parent execution",
+				zap.Error(err),
+				zap.String("execution_id", executionID),
+			)
+		} else {
+			message := map[string]interface{}{
+				"execution_id":       parent.ID,
+				"schema_id":          parent.SchemaID,
+				"current_node_id":    *execution.ParentNodeID,
+				"debug_mode":         false,
+				"child_execution_id": executionID,
+			}
+			if err := h.rmqPublisher.Publish(r.Context(), h.queueName, message); err != nil {
+				h.logger.Error("Failed to publish parent resume to RabbitMQ",
+					zap.Error(err),
+					zap.String("execution_id", parent.ID),
+				)
+			}
+		}
+	}
+
+	h.respondJSON(w, http.StatusOK, execution)
+}
+
+// Retry перезапускает упавшее выполнение с упавшей ноды с контекстом, сохранённым в её шаге
+// Тело (необязательное): {"variables": {...}} - заменить значения переменных
+// POST /api/executions/:id/retry
+func (h *ExecutionHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req domain.RetryExecutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	nodeID, message, err := h.execRepo.Retry(r.Context(), executionID, userID, req.Variables)
+	if err != nil {
+		h.respondControlError(w, executionID, err)
+		return
+	}
+
+	if err := h.rmqPublisher.Publish(r.Context(), h.queueName, message); err != nil {
+		h.execRepo.UpdateStatus(r.Context(), executionID, domain.ExecutionStatusFailed, &domain.ExecutionError{
+			Code:    domain.ErrorCodePublishFailed,
+			Message: "Failed to publish to RabbitMQ",
+			NodeID:  nodeID,
+		})
+		h.logger.Error("Failed to publish retried node to RabbitMQ",
+			zap.Error(err),
+			zap.String("execution_id", executionID),
+		)
+		h.respondError(w, http.StatusInternalServerError, "Failed to queue execution")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":      "Execution retried",
+		"execution_id": executionID,
+		"node_id":      nodeID,
+	})
+}
+
+// Fork создаёт новое выполнение той же схемы и версии, начинающееся после шага stepId
+// с сохранённым в шаге контекстом
+// Тело (необязательное): {"variables": {...}, "debug_mode": true, "debug_step": true}
+// POST /api/executions/:id/steps/:stepId/fork
+func (h *ExecutionHandler) Fork(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+	stepID, err := strconv.ParseInt(chi.URLParam(r, "stepId"), 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid step ID")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req domain.ForkExecutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	fork, message, err := h.execRepo.Fork(r.Context(), executionID, stepID, userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrExecutionNotFound), errors.Is(err, domain.ErrStepNotFound):
+			h.respondError(w, http.StatusNotFound, "Step not found")
+		case errors.Is(err, domain.ErrStepNotForkable):
+			h.respondError(w, http.StatusConflict, "Step has no next node")
+		default:
+			h.logger.Error("Failed to fork execution",
+				zap.Error(err),
+				zap.String("execution_id", executionID),
+				zap.Int64("step_id", stepID),
+			)
+			h.respondError(w, http.StatusInternalServerError, "Failed to fork execution")
+		}
+		return
+	}
+
+	if err := h.rmqPublisher.Publish(r.Context(), h.queueName, message); err != nil {
+		h.execRepo.UpdateStatus(r.Context(), fork.ID, domain.ExecutionStatusFailed, &domain.ExecutionError{
+			Code:    domain.ErrorCodePublishFailed,
+			Message: "Failed to publish to RabbitMQ",
+		})
+		h.logger.Error("Failed to publish fork to RabbitMQ",
+			zap.Error(err),
+			zap.String("execution_id", fork.ID),
+		)
+		h.respondError(w, http.StatusInternalServerError, "Failed to queue execution")
+		return
+	}
+
+	h.logger.Info("Execution forked",
+		zap.String("execution_id", fork.ID),
+		zap.String("forked_from", executionID),
+		zap.Int64("step_id", stepID),
+	)
+
+	h.respondJSON(w, http.StatusCreated, fork)
+}
+
+// DebugStep выполняет ноду, перед которой остановился отладчик, и останавливается перед следующей
+// POST /api/executions/:id/debug/step
+func (h *ExecutionHandler) DebugStep(w http.ResponseWriter, r *http.Request) {
+	h.debugAdvance(w, r, true)
+}
+
+// DebugContinue продолжает выполнение до следующей breakpoint ноды
+// POST /api/executions/:id/debug/continue
+func (h *ExecutionHandler) DebugContinue(w http.ResponseWriter, r *http.Request) {
+	h.debugAdvance(w, r, false)
+}
+
+// debugAdvance отпускает остановленные отладчиком ноды и публикует их заново
+func (h *ExecutionHandler) debugAdvance(w http.ResponseWriter, r *http.Request, step bool) {
+	executionID := chi.URLParam(r, "id")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	messages, err := h.execRepo.DebugAdvance(r.Context(), executionID, userID, step)
+	if err != nil {
+		h.respondControlError(w, executionID, err)
+		return
+	}
+
+	for _, message := range messages {
+		if err := h.rmqPublisher.Publish(r.Context(), h.queueName, message); err != nil {
+			h.execRepo.UpdateStatus(r.Context(), executionID, domain.ExecutionStatusFailed, &domain.ExecutionError{
+				Code:    domain.ErrorCodePublishFailed,
+				Message: "Failed to publish to RabbitMQ",
+			})
+			h.logger.Error("Failed to publish debug node to RabbitMQ",
+				zap.Error(err),
+				zap.String("execution_id", executionID),
+			)
+			h.respondError(w, http.StatusInternalServerError, "Failed to queue execution")
+			return
+		}
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":      "Execution advanced",
+		"execution_id": executionID,
+		"released":     len(messages),
+	})
+}
+
+// DebugVariables возвращает переменные выполнения
+// GET /api/executions/:id/debug/variables
+func (h *ExecutionHandler) DebugVariables(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	variables, err := h.execRepo.GetVariables(r.Context(), executionID, userID)
+	if err != nil {
+		h.respondControlError(w, executionID, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"execution_id": executionID,
+		"variables":    variables,
+	})
+}
+
+// SetDebugVariables заменяет переменные выполнения, остановленного отладчиком
+// PUT /api/executions/:id/debug/variables
+func (h *ExecutionHandler) SetDebugVariables(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req struct {
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Variables == nil {
+		h.respondError(w, http.StatusBadRequest, "variables must be an object")
+		return
+	}
+
+	variables, err := json.Marshal(req.Variables)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid variables")
+		return
+	}
+
+	if err := h.execRepo.SetVariables(r.Context(), executionID, userID, variables); err != nil {
+		h.respondControlError(w, executionID, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"execution_id": executionID,
+		"variables":    req.Variables,
+	})
+}
+
+// RunNode выполняет одну ноду схемы выполнения (его версии схемы) и сразу возвращает её результат
+// Выполнение не продвигается, шаги и состояние не записываются
+// POST /api/executions/:id/:id-node/one
+func (h *ExecutionHandler) RunNode(w http.ResponseWriter, r *http.Request) {
+	executionID := chi.URLParam(r, "id")
+	nodeID := chi.URLParam(r, "id-node")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	req, ok := h.decodeRunNodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	execution, err := h.execRepo.GetByID(r.Context(), executionID)
+	if err != nil || execution.CreatedBy != userID {
+		h.respondError(w, http.StatusNotFound, "Execution not found")
+		return
+	}
+	if execution.SchemaVersion == nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "Execution has no schema version")
+		return
+	}
+	schemaVersion, err := h.schemaRepo.GetVersion(r.Context(), execution.SchemaID, *execution.SchemaVersion)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "Schema version not found")
+		return
+	}
+
+	contextJSON := req.Context
+	if len(contextJSON) == 0 && req.StepID == nil {
+		// Текущий контекст выполнения (если оно уже начало работать)
+		if state, err := h.execRepo.GetState(r.Context(), executionID); err == nil {
+			contextJSON, err = json.Marshal(state.Context)
+			if err != nil {
+				h.respondError(w, http.StatusInternalServerError, "Failed to read execution context")
+				return
+			}
+		}
+	}
+
+	h.runNode(w, r, userID, schemaVersion.Definition, nodeID, req.StepID, contextJSON, &executionID, nil)
+}
+
+// RunSchemaNode выполняет одну ноду текущей версии схемы - проверка ноды при построении схемы
+// Контекст передаётся в запросе или берётся из шага любого выполнения этой схемы
+// POST /api/schemas/:id/nodes/:id-node/run
+func (h *ExecutionHandler) RunSchemaNode(w http.ResponseWriter, r *http.Request) {
+	schemaID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid schema ID")
+		return
+	}
+	nodeID := chi.URLParam(r, "id-node")
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	req, ok := h.decodeRunNodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Чужая схема выглядит так же, как несуществующая
+	schema, err := h.schemaRepo.GetByID(r.Context(), schemaID)
+	if err != nil || schema.CreatedBy != userID {
+		h.respondError(w, http.StatusNotFound, "Schema not found")
+		return
+	}
+
+	h.runNode(w, r, userID, schema.Definition, nodeID, req.StepID, req.Context, nil, &schemaID)
+}
+
+// decodeRunNodeRequest читает тело запроса на выполнение ноды (тело может быть пустым)
+func (h *ExecutionHandler) decodeRunNodeRequest(w http.ResponseWriter, r *http.Request) (*domain.RunNodeRequest, bool) {
+	var req domain.RunNodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+	if len(req.Context) > 0 && req.StepID != nil {
+		h.respondError(w, http.StatusBadRequest, "Specify either context or step_id")
+		return nil, false
+	}
+	return &req, true
+}
+
+// runNode находит ноду в definition, собирает контекст и выполняет ноду
+// Шаг step_id должен принадлежать выполнению executionID или выполнению схемы schemaID
+func (h *ExecutionHandler) runNode(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID int64,
+	definition json.RawMessage,
+	nodeID string,
+	stepID *int64,
+	contextJSON json.RawMessage,
+	executionID *string,
+	schemaID *int64,
+) {
+	var def domain.SchemaDefinition
+	if err := json.Unmarshal(definition, &def); err != nil {
+		h.respondError(w, http.StatusUnprocessableEntity, "Invalid schema definition")
+		return
+	}
+	node := executor.FindNode(&def, nodeID)
+	if node == nil {
+		h.respondError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	if stepID != nil {
+		stepCtx, err := h.execRepo.GetStepContext(r.Context(), *stepID, userID)
+		if err != nil {
+			if errors.Is(err, domain.ErrStepNotFound) {
+				h.respondError(w, http.StatusNotFound, "Step not found")
+				return
+			}
+			h.logger.Error("Failed to get step context", zap.Error(err), zap.Int64("step_id", *stepID))
+			h.respondError(w, http.StatusInternalServerError, "Failed to get step context")
+			return
+		}
+		if (executionID != nil && stepCtx.ExecutionID != *executionID) ||
+			(schemaID != nil && stepCtx.SchemaID != *schemaID) {
+			h.respondError(w, http.StatusNotFound, "Step not found")
+			return
+		}
+		contextJSON = stepCtx.Context
+	}
+
+	execCtx := &nodes.ExecutionContext{}
+	if len(contextJSON) > 0 {
+		if err := json.Unmarshal(contextJSON, execCtx); err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid context")
+			return
+		}
+	}
+
+	startedAt := time.Now()
+	result, err := h.nodeRunner.Run(r.Context(), node, execCtx)
+	if err != nil {
+		if errors.Is(err, executor.ErrNodeNotRunnable) {
+			h.respondError(w, http.StatusBadRequest, "Node type "+node.Data.Type+" cannot be run in isolation")
+			return
+		}
+		h.logger.Error("Failed to run node", zap.Error(err), zap.String("node_id", nodeID))
+		h.respondError(w, http.StatusGatewayTimeout, "Node run interrupted")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"node_id":     node.ID,
+		"node_type":   node.Data.Type,
+		"result":      result,
+		"variables":   execCtx.Variables,
+		"duration_ms": time.Since(startedAt).Milliseconds(),
+	})
+}
+
+// respondControlError отвечает на ошибку pause/resume/stop и команд отладчика
+func (h *ExecutionHandler) respondControlError(w http.ResponseWriter, executionID string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrExecutionNotFound):
+		h.respondError(w, http.StatusNotFound, "Execution not found")
+	case errors.Is(err, domain.ErrExecutionFinished):
+		h.respondError(w, http.StatusConflict, "Execution already finished")
+	case errors.Is(err, domain.ErrExecutionNotPaused):
+		h.respondError(w, http.StatusConflict, "Execution is not paused")
+	case errors.Is(err, domain.ErrExecutionNotDebug):
+		h.respondError(w, http.StatusConflict, "Execution is not in debug mode")
+	case errors.Is(err, domain.ErrExecutionNotFailed):
+		h.respondError(w, http.StatusConflict, "Execution is not failed")
+	case errors.Is(err, domain.ErrExecutionNotRetryable):
+		h.respondError(w, http.StatusConflict, "Execution cannot be retried")
+	default:
+		h.logger.Error("Failed to control execution",
+			zap.Error(err),
+			zap.String("execution_id", executionID),
+		)
+		h.respondError(w, http.StatusInternalServerError, "Failed to control execution")
+	}
+}
+
 // respondJSON отправляет JSON ответ
 func (h *ExecutionHandler) respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
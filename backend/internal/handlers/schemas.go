@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/piplexa/algomap/internal/domain"
+	"github.com/piplexa/algomap/internal/executor"
 	"github.com/piplexa/algomap/internal/middleware"
 	"github.com/piplexa/algomap/internal/repository"
 	"go.uber.org/zap"
@@ -44,11 +46,28 @@ func (h *SchemaHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	issues, ok := h.validateDefinition(w, req.Definition)
+	if !ok {
+		return
+	}
+	if domain.HasErrors(issues) {
+		// Схема с ошибками сохраняется черновиком, активировать её нельзя
+		if req.Status != nil && *req.Status == domain.SchemaStatusActive {
+			h.respondActivationRefused(w, issues)
+			return
+		}
+		if req.Status == nil {
+			draft := domain.SchemaStatusDraft
+			req.Status = &draft
+		}
+	}
+
 	schema, err := h.repo.Create(r.Context(), &req, userID)
 	if err != nil {
 		h.respondError(w, http.StatusInternalServerError, "Failed to create schema")
 		return
 	}
+	schema.Issues = issues
 
 	h.respondJSON(w, http.StatusCreated, schema)
 }
@@ -127,11 +146,46 @@ func (h *SchemaHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	schema, err := h.repo.Update(r.Context(), id, &req)
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Итоговые definition и статус: из запроса или текущие
+	var definition json.RawMessage
+	var status int16
+	if req.Definition == nil || req.Status == nil {
+		current, err := h.repo.GetByID(r.Context(), id)
+		if err != nil {
+			h.respondError(w, http.StatusNotFound, "Schema not found")
+			return
+		}
+		definition = current.Definition
+		status = current.Status
+	}
+	if req.Definition != nil {
+		definition = *req.Definition
+	}
+	if req.Status != nil {
+		status = *req.Status
+	}
+
+	issues, ok := h.validateDefinition(w, definition)
+	if !ok {
+		return
+	}
+	if status == domain.SchemaStatusActive && domain.HasErrors(issues) {
+		h.respondActivationRefused(w, issues)
+		return
+	}
+
+	schema, err := h.repo.Update(r.Context(), id, &req, userID)
 	if err != nil {
 		h.respondError(w, http.StatusInternalServerError, "Failed to update schema")
 		return
 	}
+	schema.Issues = issues
 
 	h.respondJSON(w, http.StatusOK, schema)
 }
@@ -156,6 +210,246 @@ func (h *SchemaHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Analysis возвращает отчёт статического анализа схемы: циклы без выхода, недостижимые ноды,
+// тупики, http_request без error ребра, переменные, которые никто не записывает
+// GET /api/schemas/:id/analysis
+func (h *SchemaHandler) Analysis(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid schema ID")
+		return
+	}
+
+	schema, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "Schema not found")
+		return
+	}
+
+	var def domain.SchemaDefinition
+	if len(schema.Definition) > 0 {
+		if err := json.Unmarshal(schema.Definition, &def); err != nil {
+			h.respondError(w, http.StatusUnprocessableEntity, "Invalid schema definition")
+			return
+		}
+	}
+
+	h.respondJSON(w, http.StatusOK, executor.AnalyzeSchema(&def))
+}
+
+// Versions возвращает версии схемы (без definition), новые первыми
+// GET /api/schemas/:id/versions
+func (h *SchemaHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid schema ID")
+		return
+	}
+
+	if _, err := h.repo.GetByID(r.Context(), id); err != nil {
+		h.respondError(w, http.StatusNotFound, "Schema not found")
+		return
+	}
+
+	versions, err := h.repo.ListVersions(r.Context(), id)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to list schema versions")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, versions)
+}
+
+// Version возвращает версию схемы вместе с definition
+// GET /api/schemas/:id/versions/:version
+func (h *SchemaHandler) Version(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid schema ID")
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	schemaVersion, err := h.repo.GetVersion(r.Context(), id, version)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "Schema version not found")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, schemaVersion)
+}
+
+// Diff сравнивает две версии схемы
+// GET /api/schemas/:id/diff?from=1&to=2 (to по умолчанию - текущая версия)
+func (h *SchemaHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid schema ID")
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid from version")
+		return
+	}
+
+	var to int
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err = strconv.Atoi(toStr)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid to version")
+			return
+		}
+	} else {
+		schema, err := h.repo.GetByID(r.Context(), id)
+		if err != nil {
+			h.respondError(w, http.StatusNotFound, "Schema not found")
+			return
+		}
+		to = schema.Version
+	}
+
+	fromDef, ok := h.loadVersionDefinition(w, r, id, from)
+	if !ok {
+		return
+	}
+	toDef, ok := h.loadVersionDefinition(w, r, id, to)
+	if !ok {
+		return
+	}
+
+	diff := domain.DiffDefinitions(fromDef, toDef)
+	diff.From = from
+	diff.To = to
+
+	h.respondJSON(w, http.StatusOK, diff)
+}
+
+// Rollback делает definition указанной версии текущим (создаётся новая версия)
+// Активную схему нельзя откатить к версии с ошибками - ответ 422
+// POST /api/schemas/:id/versions/:version/rollback
+func (h *SchemaHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid schema ID")
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		h.respondError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	current, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "Schema not found")
+		return
+	}
+
+	schemaVersion, err := h.repo.GetVersion(r.Context(), id, version)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "Schema version not found")
+		return
+	}
+
+	issues, ok := h.validateDefinition(w, schemaVersion.Definition)
+	if !ok {
+		return
+	}
+	if current.Status == domain.SchemaStatusActive && domain.HasErrors(issues) {
+		h.respondActivationRefused(w, issues)
+		return
+	}
+
+	schema, err := h.repo.Rollback(r.Context(), id, version, userID)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to roll back schema")
+		return
+	}
+	schema.Issues = issues
+
+	h.respondJSON(w, http.StatusOK, schema)
+}
+
+// loadVersionDefinition загружает и разбирает definition версии схемы
+// Если версии нет или definition не разбирается - отвечает ошибкой и возвращает false
+func (h *SchemaHandler) loadVersionDefinition(w http.ResponseWriter, r *http.Request, schemaID int64, version int) (*domain.SchemaDefinition, bool) {
+	schemaVersion, err := h.repo.GetVersion(r.Context(), schemaID, version)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, fmt.Sprintf("Schema version %d not found", version))
+		return nil, false
+	}
+
+	var def domain.SchemaDefinition
+	if len(schemaVersion.Definition) > 0 {
+		if err := json.Unmarshal(schemaVersion.Definition, &def); err != nil {
+			h.respondError(w, http.StatusUnprocessableEntity, "Invalid schema definition")
+			return nil, false
+		}
+	}
+
+	return &def, true
+}
+
+// NodeTypes возвращает описания типов нод (поля конфигурации, значения по умолчанию, выходы)
+// Редактор строит по ним формы настройки нод
+// GET /api/node-types
+func (h *SchemaHandler) NodeTypes(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, http.StatusOK, domain.NodeTypeSchemas())
+}
+
+// NodeType возвращает описание одного типа ноды
+// GET /api/node-types/{type}
+func (h *SchemaHandler) NodeType(w http.ResponseWriter, r *http.Request) {
+	nodeType, ok := domain.GetNodeTypeSchema(chi.URLParam(r, "type"))
+	if !ok {
+		h.respondError(w, http.StatusNotFound, "Node type not found")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, nodeType)
+}
+
+// validateDefinition проверяет граф схемы и возвращает найденные проблемы
+// Если definition не разбирается - отвечает 400 и возвращает false
+func (h *SchemaHandler) validateDefinition(w http.ResponseWriter, definition json.RawMessage) ([]domain.SchemaIssue, bool) {
+	var def domain.SchemaDefinition
+	if len(definition) > 0 && string(definition) != "null" {
+		if err := json.Unmarshal(definition, &def); err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid schema definition")
+			return nil, false
+		}
+	}
+
+	return domain.ValidateDefinition(&def), true
+}
+
+// respondActivationRefused отвечает 422: схему с ошибками нельзя сделать активной
+func (h *SchemaHandler) respondActivationRefused(w http.ResponseWriter, issues []domain.SchemaIssue) {
+	h.respondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"error":  "Schema has errors and cannot be activated",
+		"issues": issues,
+	})
+}
+
 // respondJSON отправляет JSON ответ
 func (h *SchemaHandler) respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -3,6 +3,7 @@ package nodes
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,7 +11,7 @@ import (
 
 // ConditionConfig конфигурация condition ноды
 type ConditionConfig struct {
-	Expression string `json:"expression"` // Например: "{{x}} > 10"
+	Expression string `json:"expression"` // Например: "{{x}} > 10 && status in [\"new\", \"paid\"]"
 }
 
 // ConditionHandler обработчик условий
@@ -42,13 +43,29 @@ func (h *ConditionHandler) Execute(ctx context.Context, node *Node, execCtx *Exe
 		}, nil
 	}
 
-	// 1. Интерполируем переменные в выражении
-	interpolated := InterpolateString(config.Expression, execCtx)
+	// 1. Разбираем выражение
+	expr, err := ParseExpression(config.Expression)
+	if err != nil {
+		errMsg := fmt.Sprintf("invalid expression '%s': %v", config.Expression, err)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
 
-	// 2. Вычисляем выражение
-	result, err := evaluateExpression(interpolated)
+	// 2. Вычисляем выражение (операнды берутся из контекста с их типами)
+	result, err := expr.EvaluateBool(execCtx)
+	legacy := false
+	var unknownIdent *UnknownIdentifierError
+	if errors.As(err, &unknownIdent) && strings.Contains(config.Expression, "{{") {
+		// Старая форма: строка без кавычек ({{status}} == paid) - вычисляем, как раньше,
+		// по интерполированному тексту
+		result, err = evaluateLegacyExpression(InterpolateString(config.Expression, execCtx))
+		legacy = true
+	}
 	if err != nil {
-		errMsg := fmt.Sprintf("failed to evaluate expression '%s': %v", interpolated, err)
+		errMsg := fmt.Sprintf("failed to evaluate expression '%s': %v", config.Expression, err)
 		return &NodeResult{
 			Status:     StatusFailed,
 			Error:      &errMsg,
@@ -65,24 +82,24 @@ func (h *ConditionHandler) Execute(ctx context.Context, node *Node, execCtx *Exe
 	return &NodeResult{
 		Output: map[string]interface{}{
 			"expression":   config.Expression,
-			"interpolated": interpolated,
+			"interpolated": InterpolateString(config.Expression, execCtx), // для отладки
 			"result":       result,
+			"legacy":       legacy,
 		},
 		Status:     StatusSuccess,
 		ExitHandle: exitHandle,
 	}, nil
 }
 
-// evaluateExpression вычисляет простое логическое выражение
-// Поддерживает: >, <, >=, <=, ==, !=, &&, ||
-func evaluateExpression(expr string) (bool, error) {
+// evaluateLegacyExpression вычисляет выражение старого формата по уже интерполированному тексту
+// Поддерживает: >, <, >=, <=, ==, !=, &&, || (без скобок и приоритетов, операнды - текст)
+func evaluateLegacyExpression(expr string) (bool, error) {
 	expr = strings.TrimSpace(expr)
 
 	// Обрабатываем логические операторы (И, ИЛИ)
 	if strings.Contains(expr, "||") {
-		parts := strings.Split(expr, "||")
-		for _, part := range parts {
-			result, err := evaluateExpression(strings.TrimSpace(part))
+		for _, part := range strings.Split(expr, "||") {
+			result, err := evaluateLegacyExpression(part)
 			if err != nil {
 				return false, err
 			}
@@ -94,9 +111,8 @@ func evaluateExpression(expr string) (bool, error) {
 	}
 
 	if strings.Contains(expr, "&&") {
-		parts := strings.Split(expr, "&&")
-		for _, part := range parts {
-			result, err := evaluateExpression(strings.TrimSpace(part))
+		for _, part := range strings.Split(expr, "&&") {
+			result, err := evaluateLegacyExpression(part)
 			if err != nil {
 				return false, err
 			}
@@ -108,56 +124,39 @@ func evaluateExpression(expr string) (bool, error) {
 	}
 
 	// Обрабатываем операторы сравнения
-	operators := []string{">=", "<=", "==", "!=", ">", "<"}
-	
-	for _, op := range operators {
-		if strings.Contains(expr, op) {
-			parts := strings.SplitN(expr, op, 2)
-			if len(parts) != 2 {
-				return false, fmt.Errorf("invalid expression: %s", expr)
-			}
-
-			left := strings.TrimSpace(parts[0])
-			right := strings.TrimSpace(parts[1])
-
-			return compareValues(left, right, op)
+	for _, op := range []string{">=", "<=", "==", "!=", ">", "<"} {
+		if left, right, found := strings.Cut(expr, op); found {
+			return compareLegacyValues(strings.TrimSpace(left), strings.TrimSpace(right), op), nil
 		}
 	}
 
 	// Если нет операторов - пытаемся интерпретировать как boolean
-	return parseBoolean(expr)
+	return parseLegacyBoolean(expr)
 }
 
-// compareValues сравнивает два значения
-func compareValues(left, right, operator string) (bool, error) {
-	// Пытаемся преобразовать в числа
+// compareLegacyValues сравнивает операнды как числа, если оба - числа, иначе как строки без кавычек
+func compareLegacyValues(left, right, operator string) bool {
 	leftNum, leftIsNum := parseNumber(left)
 	rightNum, rightIsNum := parseNumber(right)
-
 	if leftIsNum && rightIsNum {
-		return compareNumbers(leftNum, rightNum, operator), nil
-	}
-
-	// Если не числа - сравниваем как строки
-	return compareStrings(left, right, operator), nil
-}
-
-// parseNumber пытается распарсить строку в число
-func parseNumber(s string) (float64, bool) {
-	s = strings.TrimSpace(s)
-	
-	// Убираем кавычки если есть
-	s = strings.Trim(s, `"'`)
-	
-	num, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0, false
+		switch operator {
+		case ">":
+			return leftNum > rightNum
+		case "<":
+			return leftNum < rightNum
+		case ">=":
+			return leftNum >= rightNum
+		case "<=":
+			return leftNum <= rightNum
+		case "==":
+			return leftNum == rightNum
+		default:
+			return leftNum != rightNum
+		}
 	}
-	return num, true
-}
 
-// compareNumbers сравнивает числа
-func compareNumbers(left, right float64, operator string) bool {
+	left = strings.Trim(left, `"'`)
+	right = strings.Trim(right, `"'`)
 	switch operator {
 	case ">":
 		return left > right
@@ -169,43 +168,14 @@ func compareNumbers(left, right float64, operator string) bool {
 		return left <= right
 	case "==":
 		return left == right
-	case "!=":
-		return left != right
 	default:
-		return false
-	}
-}
-
-// compareStrings сравнивает строки
-func compareStrings(left, right, operator string) bool {
-	// Убираем кавычки
-	left = strings.Trim(strings.TrimSpace(left), `"'`)
-	right = strings.Trim(strings.TrimSpace(right), `"'`)
-
-	switch operator {
-	case "==":
-		return left == right
-	case "!=":
 		return left != right
-	case ">":
-		return left > right
-	case "<":
-		return left < right
-	case ">=":
-		return left >= right
-	case "<=":
-		return left <= right
-	default:
-		return false
 	}
 }
 
-// parseBoolean пытается распарсить строку как boolean
-func parseBoolean(s string) (bool, error) {
-	s = strings.TrimSpace(s)
-	s = strings.Trim(s, `"'`)
-	s = strings.ToLower(s)
-
+// parseLegacyBoolean интерпретирует строку как boolean
+func parseLegacyBoolean(s string) (bool, error) {
+	s = strings.ToLower(strings.Trim(strings.TrimSpace(s), `"'`))
 	switch s {
 	case "true", "1", "yes":
 		return true, nil
@@ -214,4 +184,18 @@ func parseBoolean(s string) (bool, error) {
 	default:
 		return false, fmt.Errorf("cannot parse '%s' as boolean", s)
 	}
-}
\ No newline at end of file
+}
+
+// parseNumber пытается распарсить строку в число
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	
+	// Убираем кавычки если есть
+	s = strings.Trim(s, `"'`)
+	
+	num, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return num, true
+}
@@ -0,0 +1,68 @@
+package nodes
+
+import "testing"
+
+func TestConditionHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		status     string
+		want       string
+		legacy     bool
+	}{
+		// Старые формы: значение подставлялось в текст и сравнивалось как строка
+		{"double quoted template", `"{{status}}" == "paid"`, "paid", "true", false},
+		{"single quoted template", `'{{status}}' == 'paid'`, "paid", "true", false},
+		{"single quoted template mismatch", `'{{status}}' == 'paid'`, "new", "false", false},
+		{"template in the middle of a string", `'order: {{status}}' == 'order: paid'`, "paid", "true", false},
+		{"unquoted string", `{{status}} == paid`, "paid", "true", true},
+		{"unquoted string mismatch", `{{status}} == paid`, "new", "false", true},
+		{"unquoted string not equal", `{{status}} != paid && {{amount}} > 100`, "new", "true", true},
+
+		// Новый синтаксис
+		{"typed operands", `{{variables.amount}} > 100 && status in ['new', 'paid']`, "new", "true", false},
+		{"typed operands mismatch", `amount > 200 || status == 'paid'`, "new", "false", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execCtx := &ExecutionContext{Variables: map[string]interface{}{
+				"status": tt.status,
+				"amount": 150,
+			}}
+			result := runNode(t, NewConditionHandler(), "condition", map[string]interface{}{"expression": tt.expression}, execCtx)
+			assertSuccess(t, result)
+			if result.ExitHandle != tt.want {
+				t.Errorf("exit handle = %q, want %q", result.ExitHandle, tt.want)
+			}
+			if got := result.Output["legacy"]; got != tt.legacy {
+				t.Errorf("legacy = %v, want %v", got, tt.legacy)
+			}
+		})
+	}
+}
+
+func TestConditionHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		message    string
+	}{
+		{"missing expression", "", "expression is required"},
+		{"parse error", "status = 'paid'", "parse error at position 8"},
+		{"unknown identifier", "missing == 1", "unknown identifier 'missing' at position 1"},
+		{"unknown identifier without templates", "status == paid", "unknown identifier 'paid' at position 11"},
+		{"evaluation error", "{{amount}} / 0 > 1", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execCtx := &ExecutionContext{Variables: map[string]interface{}{
+				"status": "paid",
+				"amount": 150,
+			}}
+			result := runNode(t, NewConditionHandler(), "condition", map[string]interface{}{"expression": tt.expression}, execCtx)
+			assertFailed(t, result, tt.message)
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package nodes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Режимы db_query ноды
+const (
+	DBQueryModeSelect = "select" // вернуть строки
+	DBQueryModeExec   = "exec"   // INSERT/UPDATE/DELETE, вернуть количество затронутых строк
+)
+
+// DefaultDBQueryMaxRows ограничение количества строк select по умолчанию
+// Время запроса ограничено таймаутом ноды (data.timeout)
+const DefaultDBQueryMaxRows = 1000
+
+// DBQueryConfig конфигурация db_query ноды
+type DBQueryConfig struct {
+	DataSource string `json:"data_source"`        // имя подключения из конфигурации воркера (DATASOURCE_<NAME>_URL)
+	Query      string `json:"query"`              // "SELECT * FROM orders WHERE id = {{webhook.payload.order_id}}"
+	Mode       string `json:"mode,omitempty"`     // select (по умолчанию) или exec
+	MaxRows    int    `json:"max_rows,omitempty"` // ограничение количества строк для select
+}
+
+// DBQueryHandler обработчик SQL запросов к внешним БД
+// Подключения берутся только из конфигурации воркера, схема ссылается на них по имени
+type DBQueryHandler struct {
+	dataSources map[string]*sql.DB
+}
+
+// NewDBQueryHandler создаёт новый DBQueryHandler
+func NewDBQueryHandler(dataSources map[string]*sql.DB) *DBQueryHandler {
+	return &DBQueryHandler{
+		dataSources: dataSources,
+	}
+}
+
+// Execute выполняет db_query ноду
+func (h *DBQueryHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	var config DBQueryConfig
+	if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+		errMsg := fmt.Sprintf("failed to parse db_query config: %v", err)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	db, ok := h.dataSources[strings.ToLower(config.DataSource)]
+	if !ok {
+		errMsg := fmt.Sprintf("unknown data source: %s", config.DataSource)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	if strings.TrimSpace(config.Query) == "" {
+		errMsg := "query is required"
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// 1. {{...}} -> $1, $2... Значения передаются параметрами, а не подстановкой в текст запроса
+	query, args, err := bindQueryParams(config.Query, execCtx)
+	if err != nil {
+		errMsg := err.Error()
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// 2. Выполняем
+	var output map[string]interface{}
+	switch config.Mode {
+	case DBQueryModeSelect, "":
+		maxRows := config.MaxRows
+		if maxRows <= 0 {
+			maxRows = DefaultDBQueryMaxRows
+		}
+		output, err = querySelect(ctx, db, query, args, maxRows)
+	case DBQueryModeExec:
+		output, err = queryExec(ctx, db, query, args)
+	default:
+		err = fmt.Errorf("invalid mode: %s", config.Mode)
+	}
+
+	if err != nil {
+		errMsg := fmt.Sprintf("query failed: %v", err)
+		return &NodeResult{
+			Output: map[string]interface{}{
+				"error": err.Error(),
+			},
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	return &NodeResult{
+		Output:     output,
+		Status:     StatusSuccess,
+		ExitHandle: "success",
+	}, nil
+}
+
+// bindQueryParams заменяет каждый {{...}} на позиционный параметр и возвращает значения параметров
+// Объекты и массивы передаются как JSON (подходит для json/jsonb колонок).
+// {{...}} внутри кавычек или комментария - ошибка: '{{x}}' стал бы строкой '$1', а не параметром
+func bindQueryParams(query string, execCtx *ExecutionContext) (string, []interface{}, error) {
+	var args []interface{}
+	var bound strings.Builder
+	quoted := sqlQuotedRanges(query)
+
+	last := 0
+	for _, loc := range varPattern.FindAllStringIndex(query, -1) {
+		match := query[loc[0]:loc[1]]
+		expr := strings.TrimSpace(match[2 : len(match)-2])
+		for _, r := range quoted {
+			if loc[0] >= r[0] && loc[0] < r[1] {
+				return "", nil, fmt.Errorf("query parameter %s must not be inside quotes or a comment", match)
+			}
+		}
+
+		value, ok := ResolveReference(execCtx, expr)
+		if !ok {
+			return "", nil, fmt.Errorf("query parameter not found: %s", expr)
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}, []interface{}:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to encode query parameter %s: %w", expr, err)
+			}
+			value = string(data)
+		}
+
+		args = append(args, value)
+		bound.WriteString(query[last:loc[0]])
+		fmt.Fprintf(&bound, "$%d", len(args))
+		last = loc[1]
+	}
+	bound.WriteString(query[last:])
+
+	return bound.String(), args, nil
+}
+
+// sqlQuotedRanges возвращает диапазоны [начало, конец) запроса, которые PostgreSQL не разбирает как код:
+// строки '...' и E'...', идентификаторы "...", строки в долларах $tag$...$tag$ и комментарии -- и /* */
+func sqlQuotedRanges(query string) [][2]int {
+	var ranges [][2]int
+	for i := 0; i < len(query); i++ {
+		start := i
+		switch {
+		case query[i] == '\'' || query[i] == '"':
+			// Кавычка внутри экранируется удвоением ('' и "") - это просто два литерала подряд.
+			// В строке E'...' ещё и обратным слешем: E'it\'s' - одна строка
+			quote := query[i]
+			escapes := quote == '\'' && isEscapeStringPrefix(query, i)
+			i++
+			for i < len(query) && query[i] != quote {
+				if escapes && query[i] == '\\' {
+					i++
+				}
+				i++
+			}
+		case strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += 2 + end + 1
+			}
+		case query[i] == '$':
+			tag := dollarQuoteTag(query[i:])
+			if tag == "" {
+				continue
+			}
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += len(tag) + end + len(tag) - 1
+			}
+		default:
+			continue
+		}
+		ranges = append(ranges, [2]int{start, min(i+1, len(query))})
+	}
+	return ranges
+}
+
+// isEscapeStringPrefix стоит ли перед кавычкой в позиции i префикс E (строка с экранированием E'...')
+// Буква E в конце идентификатора (name') префиксом не является
+func isEscapeStringPrefix(query string, i int) bool {
+	if i == 0 || (query[i-1] != 'E' && query[i-1] != 'e') {
+		return false
+	}
+	if i == 1 {
+		return true
+	}
+	c := query[i-2]
+	return !(c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
+
+// dollarQuoteTag возвращает открывающий тег строки в долларах ($$ или $name$) в начале s, иначе ""
+// $1 - позиционный параметр, а не тег: имя тега не начинается с цифры
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || (i > 1 && c >= '0' && c <= '9'):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// querySelect выполняет запрос и возвращает строки как массив объектов
+func querySelect(ctx context.Context, db *sql.DB, query string, args []interface{}, maxRows int) (map[string]interface{}, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]interface{}, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = ct.Name()
+	}
+
+	result := make([]interface{}, 0)
+	truncated := false
+	for rows.Next() {
+		if len(result) >= maxRows {
+			truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columnTypes))
+		pointers := make([]interface{}, len(columnTypes))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columnTypes))
+		for i, ct := range columnTypes {
+			row[ct.Name()] = convertColumnValue(values[i], ct.DatabaseTypeName())
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"rows":      result,
+		"row_count": len(result),
+		"columns":   columns,
+		"truncated": truncated,
+	}, nil
+}
+
+// queryExec выполняет запрос без результата
+func queryExec(ctx context.Context, db *sql.DB, query string, args []interface{}) (map[string]interface{}, error) {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"rows_affected": affected,
+	}, nil
+}
+
+// convertColumnValue приводит значение колонки к виду, пригодному для контекста
+// json/jsonb разбираются в объекты, остальные байты - строки
+func convertColumnValue(value interface{}, dbType string) interface{} {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return value
+	}
+
+	if dbType == "JSON" || dbType == "JSONB" {
+		var parsed interface{}
+		if err := json.Unmarshal(raw, &parsed); err == nil {
+			return parsed
+		}
+	}
+	return string(raw)
+}
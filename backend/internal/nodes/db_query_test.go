@@ -0,0 +1,73 @@
+package nodes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindQueryParams(t *testing.T) {
+	execCtx := &ExecutionContext{Variables: map[string]interface{}{
+		"id":    42,
+		"name":  "it's",
+		"attrs": map[string]interface{}{"a": 1},
+	}}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		args  []interface{}
+	}{
+		{"parameters", "SELECT * FROM t WHERE id = {{id}} AND name = {{ name }}", "SELECT * FROM t WHERE id = $1 AND name = $2", []interface{}{42, "it's"}},
+		{"object as json", "UPDATE t SET attrs = {{attrs}}", "UPDATE t SET attrs = $1", []interface{}{`{"a":1}`}},
+		{"doubled quote", "SELECT 'it''s', {{id}}", "SELECT 'it''s', $1", []interface{}{42}},
+		{"escape string", `SELECT E'it\'s', {{id}}`, `SELECT E'it\'s', $1`, []interface{}{42}},
+		{"escaped backslash", `SELECT E'\\', {{id}}`, `SELECT E'\\', $1`, []interface{}{42}},
+		{"identifier ending with e", `SELECT name FROM type WHERE x = 'a\' AND id = {{id}}`, `SELECT name FROM type WHERE x = 'a\' AND id = $1`, []interface{}{42}},
+		{"dollar quoted", "SELECT $q$it's$q$, {{id}}", "SELECT $q$it's$q$, $1", []interface{}{42}},
+		{"comment after parameter", "SELECT {{id}} -- comment", "SELECT $1 -- comment", []interface{}{42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := bindQueryParams(tt.query, execCtx)
+			if err != nil {
+				t.Fatalf("bindQueryParams error: %v", err)
+			}
+			if query != tt.want {
+				t.Errorf("query = %q, want %q", query, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestBindQueryParamsErrors(t *testing.T) {
+	execCtx := &ExecutionContext{Variables: map[string]interface{}{"id": 42}}
+
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"inside quotes", "SELECT * FROM t WHERE name = '{{id}}'", "must not be inside quotes"},
+		{"inside identifier", `SELECT "{{id}}" FROM t`, "must not be inside quotes"},
+		{"inside escape string", `SELECT E'it\'s {{id}}'`, "must not be inside quotes"},
+		{"inside dollar quotes", "SELECT $q$ {{id}} $q$", "must not be inside quotes"},
+		{"inside line comment", "SELECT 1 -- {{id}}", "must not be inside quotes"},
+		{"inside block comment", "SELECT 1 /* {{id}} */", "must not be inside quotes"},
+		{"unknown parameter", "SELECT {{missing}}", "query parameter not found: missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := bindQueryParams(tt.query, execCtx)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.message)
+			}
+		})
+	}
+}
@@ -2,8 +2,19 @@ package nodes
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/piplexa/algomap/internal/domain"
 )
 
+// EndConfig конфигурация end ноды
+type EndConfig struct {
+	Success *bool                  `json:"success,omitempty"` // по умолчанию true
+	Message string                 `json:"message,omitempty"` // "Заказ {{variables.order_id}} обработан"
+	Output  map[string]interface{} `json:"output,omitempty"`  // итоговый результат выполнения: {"total": "{{variables.total}}"}
+}
+
 // EndHandler обработчик завершающей ноды
 type EndHandler struct{}
 
@@ -13,13 +24,49 @@ func NewEndHandler() *EndHandler {
 }
 
 // Execute выполняет end ноду
-// End нода просто фиксирует завершение выполнения
+// End нода фиксирует завершение выполнения и формирует его итоговый результат (main.executions.output)
 func (h *EndHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
-	return &NodeResult{
+	var config EndConfig
+	if len(node.Data.Config) > 0 {
+		if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+			errMsg := fmt.Sprintf("failed to parse end config: %v", err)
+			return &NodeResult{
+				Status: StatusFailed,
+				Error:  &errMsg,
+			}, nil
+		}
+	}
+
+	success := config.Success == nil || *config.Success
+	message := InterpolateString(config.Message, execCtx)
+
+	// Итоговый результат строится с сохранением типов (см. InterpolateValue)
+	var output interface{}
+	if config.Output != nil {
+		output = InterpolateValue(config.Output, execCtx)
+	}
+
+	result := &NodeResult{
 		Output: map[string]interface{}{
 			"completed": true,
+			"success":   success,
+			"message":   message,
+			"output":    output,
 		},
 		Status: StatusSuccess,
 		// NextNodeID nil означает, что это последняя нода
-	}, nil
+	}
+
+	if !success {
+		// Выполнение завершается со статусом failed, итоговый результат при этом сохраняется
+		errMsg := message
+		if errMsg == "" {
+			errMsg = "execution finished with success: false"
+		}
+		result.Status = StatusFailed
+		result.Error = &errMsg
+		result.ErrorCode = domain.ErrorCodeEndFailed
+	}
+
+	return result, nil
 }
@@ -0,0 +1,61 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ErrorConfig конфигурация error ноды
+type ErrorConfig struct {
+	Code    string      `json:"code"`              // "order_not_found"
+	Message string      `json:"message"`           // "Заказ {{webhook.payload.order_id}} не найден"
+	Details interface{} `json:"details,omitempty"` // произвольные данные, интерполируются
+}
+
+// ErrorHandler обработчик error ноды
+// Завершает выполнение с бизнес-ошибкой: код, сообщение и детали попадают в main.executions.error
+type ErrorHandler struct{}
+
+// NewErrorHandler создаёт новый ErrorHandler
+func NewErrorHandler() *ErrorHandler {
+	return &ErrorHandler{}
+}
+
+// Execute выполняет error ноду
+func (h *ErrorHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	var config ErrorConfig
+	if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+		errMsg := fmt.Sprintf("failed to parse error config: %v", err)
+		return &NodeResult{
+			Status: StatusFailed,
+			Error:  &errMsg,
+		}, nil
+	}
+
+	if config.Code == "" {
+		errMsg := "error code is required"
+		return &NodeResult{
+			Status: StatusFailed,
+			Error:  &errMsg,
+		}, nil
+	}
+
+	message := InterpolateString(config.Message, execCtx)
+	if message == "" {
+		message = config.Code
+	}
+	details := InterpolateValue(config.Details, execCtx)
+
+	return &NodeResult{
+		Output: map[string]interface{}{
+			"code":    config.Code,
+			"message": message,
+			"details": details,
+		},
+		Status:    StatusFailed,
+		Error:     &message,
+		ErrorCode: config.Code,
+		ErrorData: details,
+	}, nil
+}
@@ -0,0 +1,528 @@
+package nodes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Язык выражений (condition, math и другие ноды)
+//
+// Операнды берутся из ExecutionContext с их типами, а не из предварительно интерполированного текста:
+//   {{steps.http_1.output.status}} == 200 && !variables.blocked
+//   status in ["new", "paid"] || startsWith(webhook.payload.email, "admin@")
+//   len(steps.http_1.output.body.items) > 0
+//
+// {{...}} внутри строковых литералов подставляются как текст: "{{status}}" == "paid".
+// Идентификатор, который не найден в контексте, - ошибка (а не null): в старых схемах
+// так записывались строки без кавычек ({{status}} == paid), см. ConditionHandler.
+//
+// Приоритет операторов (от низкого к высокому):
+//   ||
+//   &&
+//   == !=
+//   < <= > >= in
+//   + -
+//   * / %
+//   ! - (унарные)
+//   .поле [индекс] вызов()
+
+// ParseError ошибка разбора выражения с позицией (номер символа, начиная с 1)
+type ParseError struct {
+	Pos     int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse error at position %d: %s", e.Pos, e.Message)
+}
+
+// Expression разобранное выражение
+type Expression struct {
+	source string
+	root   exprNode
+}
+
+// ParseExpression разбирает выражение
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{source: source, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", tok.describe()))
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String возвращает исходный текст выражения
+func (e *Expression) String() string {
+	return e.source
+}
+
+// ===== Лексер =====
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokRef // {{path}}
+	tokOp
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // оператор, идентификатор, путь внутри {{}}
+	value interface{}
+	pos   int // байтовое смещение в исходной строке
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("string %q", t.value)
+	case tokRef:
+		return fmt.Sprintf("'{{%s}}'", t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+// Операторы: сначала двухсимвольные, чтобы "<=" не разобрался как "<"
+var exprOperators = []string{
+	"&&", "||", "==", "!=", "<=", ">=",
+	"+", "-", "*", "/", "%", "!", "<", ">", "(", ")", "[", "]", ",", ".",
+}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(source); {
+		c := source[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case strings.HasPrefix(source[i:], "{{"):
+			end := strings.Index(source[i:], "}}")
+			if end < 0 {
+				return nil, newParseError(source, i, "unclosed '{{'")
+			}
+			path := strings.TrimSpace(source[i+2 : i+end])
+			if path == "" {
+				return nil, newParseError(source, i, "empty '{{}}'")
+			}
+			tokens = append(tokens, token{kind: tokRef, text: path, pos: i})
+			i += end + 2
+
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(source) && isDigit(source[i]) {
+				i++
+			}
+			// После точки-оператора (items.0.name) - только целая часть
+			afterDot := len(tokens) > 0 && tokens[len(tokens)-1].kind == tokOp && tokens[len(tokens)-1].text == "."
+			if !afterDot && i+1 < len(source) && source[i] == '.' && isDigit(source[i+1]) {
+				i++
+				for i < len(source) && isDigit(source[i]) {
+					i++
+				}
+			}
+			// Экспонента: 1e6, 2.5E-3
+			if !afterDot && i < len(source) && (source[i] == 'e' || source[i] == 'E') {
+				j := i + 1
+				if j < len(source) && (source[j] == '+' || source[j] == '-') {
+					j++
+				}
+				if j < len(source) && isDigit(source[j]) {
+					i = j
+					for i < len(source) && isDigit(source[i]) {
+						i++
+					}
+				}
+			}
+			text := source[start:i]
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, newParseError(source, start, fmt.Sprintf("invalid number '%s'", text))
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, value: num, pos: start})
+
+		case c == '"' || c == '\'':
+			str, n, err := readStringLiteral(source[i:])
+			if err != nil {
+				return nil, newParseError(source, i, err.Error())
+			}
+			tokens = append(tokens, token{kind: tokString, text: source[i : i+n], value: str, pos: i})
+			i += n
+
+		case isIdentStart(c):
+			start := i
+			for i < len(source) && isIdentPart(source[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: source[start:i], pos: start})
+
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				if c == '=' {
+					return nil, newParseError(source, i, "unexpected '=', use '==' for comparison")
+				}
+				r, _ := utf8.DecodeRuneInString(source[i:])
+				return nil, newParseError(source, i, fmt.Sprintf("unexpected character '%c'", r))
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(source)}), nil
+}
+
+// readStringLiteral читает строку в одинарных или двойных кавычках, возвращает значение и длину
+func readStringLiteral(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+// newParseError переводит байтовое смещение в номер символа
+func newParseError(source string, offset int, message string) *ParseError {
+	if offset > len(source) {
+		offset = len(source)
+	}
+	return &ParseError{
+		Pos:     utf8.RuneCountInString(source[:offset]) + 1,
+		Message: message,
+	}
+}
+
+// ===== AST =====
+
+type exprNode interface{}
+
+type literalNode struct {
+	value interface{}
+}
+
+// refNode {{path}} - путь разрешается через ResolveReference (с фильтрами)
+type refNode struct {
+	path string
+}
+
+// identNode корень пути: variables, steps, item, имя переменной...
+type identNode struct {
+	name string
+	pos  int
+}
+
+// templateNode строковый литерал с {{...}} - интерполируется при вычислении
+type templateNode struct {
+	text string
+}
+
+type memberNode struct {
+	object exprNode
+	field  string
+}
+
+type indexNode struct {
+	object exprNode
+	index  exprNode
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+	pos     int
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+	pos         int
+}
+
+type callNode struct {
+	name string
+	args []exprNode
+	pos  int
+}
+
+type arrayNode struct {
+	items []exprNode
+}
+
+// ===== Парсер (рекурсивный спуск) =====
+
+type exprParser struct {
+	source string
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOp проверяет, что текущий токен - один из операторов
+func (p *exprParser) isOp(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokOp && !(tok.kind == tokIdent && tok.text == "in") {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	tok := p.peek()
+	if tok.kind != tokOp || tok.text != op {
+		return p.errorAt(tok, fmt.Sprintf("expected '%s', got %s", op, tok.describe()))
+	}
+	p.next()
+	return nil
+}
+
+func (p *exprParser) errorAt(tok token, message string) *ParseError {
+	return newParseError(p.source, tok.pos, message)
+}
+
+// parseBinary разбирает левоассоциативную цепочку операторов одного уровня
+func (p *exprParser) parseBinary(operand func() (exprNode, error), ops ...string) (exprNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(ops...) {
+		tok := p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: tok.text, left: left, right: right, pos: tok.pos}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	return p.parseBinary(p.parseEquality, "&&")
+}
+
+func (p *exprParser) parseEquality() (exprNode, error) {
+	return p.parseBinary(p.parseComparison, "==", "!=")
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	return p.parseBinary(p.parseAdditive, "<", "<=", ">", ">=", "in")
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOp("!", "-") {
+		tok := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: tok.text, operand: operand, pos: tok.pos}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *exprParser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.isOp("."):
+			p.next()
+			tok := p.next()
+			// После точки допускается и число: items.0
+			if tok.kind != tokIdent && tok.kind != tokNumber {
+				return nil, p.errorAt(tok, fmt.Sprintf("expected field name, got %s", tok.describe()))
+			}
+			node = &memberNode{object: node, field: tok.text}
+
+		case p.isOp("["):
+			p.next()
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &indexNode{object: node, index: index}
+
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokNumber:
+		return &literalNode{value: tok.value}, nil
+
+	case tokString:
+		if str := tok.value.(string); strings.Contains(str, "{{") {
+			return &templateNode{text: str}, nil
+		}
+		return &literalNode{value: tok.value}, nil
+
+	case tokRef:
+		return &refNode{path: tok.text}, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		case "in":
+			return nil, p.errorAt(tok, "unexpected 'in'")
+		}
+
+		// Вызов функции
+		if p.isOp("(") {
+			p.next()
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{name: tok.text, args: args, pos: tok.pos}, nil
+		}
+		return &identNode{name: tok.text, pos: tok.pos}, nil
+
+	case tokOp:
+		switch tok.text {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return node, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &arrayNode{items: items}, nil
+		}
+	}
+
+	return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", tok.describe()))
+}
+
+// parseList разбирает элементы через запятую до закрывающей скобки
+func (p *exprParser) parseList(closing string) ([]exprNode, error) {
+	var items []exprNode
+	if p.isOp(closing) {
+		p.next()
+		return items, nil
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.isOp(",") {
+			p.next()
+			continue
+		}
+		if err := p.expect(closing); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+}
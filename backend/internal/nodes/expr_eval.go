@@ -0,0 +1,535 @@
+package nodes
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// exprFunc функция, доступная в выражениях
+type exprFunc func(args []interface{}) (interface{}, error)
+
+// exprFunctions функции, доступные в выражениях (математические добавляются в expr_math.go)
+var exprFunctions = map[string]exprFunc{
+	"contains":   fnContains,
+	"startsWith": fnStartsWith,
+	"endsWith":   fnEndsWith,
+	"len":        fnLen,
+	"lower":      fnLower,
+	"upper":      fnUpper,
+	"trim":       fnTrim,
+}
+
+// UnknownIdentifierError идентификатор не найден в контексте: это не корень пути и не переменная
+type UnknownIdentifierError struct {
+	Name string
+	Pos  int // номер символа, начиная с 1
+}
+
+func (e *UnknownIdentifierError) Error() string {
+	return fmt.Sprintf("unknown identifier '%s' at position %d", e.Name, e.Pos)
+}
+
+// exprEnv окружение вычисления выражения
+type exprEnv struct {
+	source  string
+	ctx     *ExecutionContext
+	funcs   map[string]exprFunc
+	decimal bool // арифметика в big.Rat (см. EvaluateNumber)
+}
+
+// Evaluate вычисляет выражение в контексте выполнения
+// Не найденные поля и {{пути}} дают null, не найденный идентификатор - UnknownIdentifierError, числа - float64
+func (e *Expression) Evaluate(ctx *ExecutionContext) (interface{}, error) {
+	env := &exprEnv{source: e.source, ctx: ctx, funcs: exprFunctions}
+	return env.eval(e.root)
+}
+
+// EvaluateBool вычисляет выражение и приводит результат к bool (см. Truthy)
+func (e *Expression) EvaluateBool(ctx *ExecutionContext) (bool, error) {
+	value, err := e.Evaluate(ctx)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(value), nil
+}
+
+// Truthy приводит значение к bool: null, false, 0, "" и пустые массивы/объекты - ложь
+func Truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case *big.Rat:
+		return v.Sign() != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+func (env *exprEnv) errorf(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), env.position(pos))
+}
+
+// position переводит байтовое смещение в номер символа
+func (env *exprEnv) position(pos int) int {
+	if pos > len(env.source) {
+		pos = len(env.source)
+	}
+	return utf8.RuneCountInString(env.source[:pos]) + 1
+}
+
+func (env *exprEnv) eval(node exprNode) (interface{}, error) {
+	switch n := node.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *refNode:
+		value, _ := ResolveReference(env.ctx, n.path)
+		return normalizeExprValue(value), nil
+
+	case *identNode:
+		value, ok := ResolvePath(env.ctx, n.name)
+		if !ok {
+			return nil, &UnknownIdentifierError{Name: n.name, Pos: env.position(n.pos)}
+		}
+		return normalizeExprValue(value), nil
+
+	case *templateNode:
+		return InterpolateString(n.text, env.ctx), nil
+
+	case *memberNode:
+		object, err := env.eval(n.object)
+		if err != nil {
+			return nil, err
+		}
+		value, _ := lookupField(object, n.field)
+		return normalizeExprValue(value), nil
+
+	case *indexNode:
+		object, err := env.eval(n.object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := env.eval(n.index)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		switch i := index.(type) {
+		case float64:
+			value, _ = lookupIndex(object, int(i))
+		case string:
+			value, _ = lookupField(object, i)
+		}
+		return normalizeExprValue(value), nil
+
+	case *arrayNode:
+		items := make([]interface{}, len(n.items))
+		for i, item := range n.items {
+			value, err := env.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = value
+		}
+		return items, nil
+
+	case *unaryNode:
+		operand, err := env.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return !Truthy(operand), nil
+		}
+		if r, ok := operand.(*big.Rat); ok {
+			return new(big.Rat).Neg(r), nil
+		}
+		num, ok := toExprNumber(operand)
+		if !ok {
+			return nil, env.errorf(n.pos, "cannot negate %s", describeType(operand))
+		}
+		return -num, nil
+
+	case *binaryNode:
+		return env.evalBinary(n)
+
+	case *callNode:
+		fn, ok := env.funcs[n.name]
+		if !ok {
+			return nil, env.errorf(n.pos, "unknown function '%s'", n.name)
+		}
+		args := make([]interface{}, len(n.args))
+		for i, arg := range n.args {
+			value, err := env.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+		}
+		result, err := fn(args)
+		if err != nil {
+			return nil, env.errorf(n.pos, "%s(): %v", n.name, err)
+		}
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("unknown expression node %T", node)
+	}
+}
+
+func (env *exprEnv) evalBinary(n *binaryNode) (interface{}, error) {
+	left, err := env.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+
+	// Логические операторы - с коротким замыканием
+	switch n.op {
+	case "&&":
+		if !Truthy(left) {
+			return false, nil
+		}
+		right, err := env.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	case "||":
+		if Truthy(left) {
+			return true, nil
+		}
+		right, err := env.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return Truthy(right), nil
+	}
+
+	right, err := env.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+
+	case "<", "<=", ">", ">=":
+		// Сравнение с null всегда ложно
+		if left == nil || right == nil {
+			return false, nil
+		}
+		cmp, err := compareOrdered(left, right)
+		if err != nil {
+			return nil, env.errorf(n.pos, "%v", err)
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+
+	case "in":
+		result, err := containsValue(right, left)
+		if err != nil {
+			return nil, env.errorf(n.pos, "'in': %v", err)
+		}
+		return result, nil
+
+	case "+":
+		// Строка с чем угодно - конкатенация
+		// (в режиме decimal числа в строках складываются: результаты decimal хранятся строками)
+		_, leftIsStr := left.(string)
+		_, rightIsStr := right.(string)
+		if leftIsStr || rightIsStr {
+			_, leftIsNum := toRat(left)
+			_, rightIsNum := toRat(right)
+			if !env.decimal || !leftIsNum || !rightIsNum {
+				return formatValue(left) + formatValue(right), nil
+			}
+		}
+	}
+
+	if env.decimal {
+		return env.evalDecimal(n, left, right)
+	}
+	return env.evalArithmetic(n, left, right)
+}
+
+// evalArithmetic вычисляет + - * / % над числами (числа в строках тоже допускаются)
+func (env *exprEnv) evalArithmetic(n *binaryNode, left, right interface{}) (interface{}, error) {
+	l, ok := toExprNumber(left)
+	if !ok {
+		return nil, env.errorf(n.pos, "operator '%s': left operand is %s, not a number", n.op, describeType(left))
+	}
+	r, ok := toExprNumber(right)
+	if !ok {
+		return nil, env.errorf(n.pos, "operator '%s': right operand is %s, not a number", n.op, describeType(right))
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, env.errorf(n.pos, "division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, env.errorf(n.pos, "modulo by zero")
+		}
+		return math.Mod(l, r), nil
+	default:
+		return nil, env.errorf(n.pos, "unknown operator '%s'", n.op)
+	}
+}
+
+// normalizeExprValue приводит числа к float64, чтобы операторы работали с одним числовым типом
+func normalizeExprValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case map[string]string:
+		result := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			result[k] = val
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// toExprNumber возвращает число из float64 или строки с числом
+func toExprNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case *big.Rat:
+		num, _ := v.Float64()
+		return num, true
+	case string:
+		num, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return num, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// valuesEqual сравнивает значения; число и строка с числом равны ("10" == 10)
+func valuesEqual(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+
+	// decimal значения сравниваем точно
+	_, leftIsRat := left.(*big.Rat)
+	_, rightIsRat := right.(*big.Rat)
+	if leftIsRat || rightIsRat {
+		l, lok := toRat(left)
+		r, rok := toRat(right)
+		return lok && rok && l.Cmp(r) == 0
+	}
+
+	_, leftIsNum := left.(float64)
+	_, rightIsNum := right.(float64)
+	if leftIsNum || rightIsNum {
+		l, lok := toExprNumber(left)
+		r, rok := toExprNumber(right)
+		if lok && rok {
+			return l == r
+		}
+	}
+
+	return reflect.DeepEqual(left, right)
+}
+
+// compareOrdered сравнивает числа (в т.ч. числа в строках) или строки
+func compareOrdered(left, right interface{}) (int, error) {
+	_, leftIsRat := left.(*big.Rat)
+	_, rightIsRat := right.(*big.Rat)
+	if leftIsRat || rightIsRat {
+		if l, lok := toRat(left); lok {
+			if r, rok := toRat(right); rok {
+				return l.Cmp(r), nil
+			}
+		}
+	}
+
+	l, lok := toExprNumber(left)
+	r, rok := toExprNumber(right)
+	if lok && rok {
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	}
+
+	ls, lIsStr := left.(string)
+	rs, rIsStr := right.(string)
+	if lIsStr && rIsStr {
+		return strings.Compare(ls, rs), nil
+	}
+
+	return 0, fmt.Errorf("cannot compare %s and %s", describeType(left), describeType(right))
+}
+
+// containsValue проверяет вхождение: элемент в массиве, подстрока в строке, ключ в объекте
+func containsValue(container, value interface{}) (bool, error) {
+	switch c := container.(type) {
+	case nil:
+		return false, nil
+	case []interface{}:
+		for _, item := range c {
+			if valuesEqual(item, value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		return strings.Contains(c, formatValue(value)), nil
+	case map[string]interface{}:
+		key, ok := value.(string)
+		if !ok {
+			return false, nil
+		}
+		_, exists := c[key]
+		return exists, nil
+	default:
+		return false, fmt.Errorf("expected array, string or object, got %s", describeType(container))
+	}
+}
+
+// describeType название типа значения для сообщений об ошибках
+func describeType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64, *big.Rat:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// ===== Функции =====
+
+func expectArgs(args []interface{}, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("expected %d arguments, got %d", n, len(args))
+	}
+	return nil
+}
+
+func fnContains(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+	return containsValue(args[0], args[1])
+}
+
+func fnStartsWith(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return false, nil
+	}
+	return strings.HasPrefix(formatValue(args[0]), formatValue(args[1])), nil
+}
+
+func fnEndsWith(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return false, nil
+	}
+	return strings.HasSuffix(formatValue(args[0]), formatValue(args[1])), nil
+}
+
+func fnLen(args []interface{}) (interface{}, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return float64(0), nil
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	default:
+		return nil, fmt.Errorf("expected string, array or object, got %s", describeType(args[0]))
+	}
+}
+
+// stringArg функции над строками: null остаётся null, скаляры приводятся к строке
+func stringArg(args []interface{}, fn func(string) string) (interface{}, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	return fn(formatValue(args[0])), nil
+}
+
+func fnLower(args []interface{}) (interface{}, error) {
+	return stringArg(args, strings.ToLower)
+}
+
+func fnUpper(args []interface{}) (interface{}, error) {
+	return stringArg(args, strings.ToUpper)
+}
+
+func fnTrim(args []interface{}) (interface{}, error) {
+	return stringArg(args, strings.TrimSpace)
+}
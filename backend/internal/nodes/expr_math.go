@@ -0,0 +1,418 @@
+package nodes
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Математика в выражениях: функции и режим decimal
+//
+// В обычном режиме числа - float64. В режиме decimal арифметика идёт в big.Rat без потерь точности
+// (0.1 + 0.2 == 0.3), это режим для денег. Результат decimal вычисления - строка с десятичным числом.
+
+// decimalFractionDigits сколько знаков после запятой выводится для бесконечных дробей (1/3) в режиме decimal
+const decimalFractionDigits = 18
+
+// maxDecimalPowExponent ограничение степени в pow для режима decimal (числа растут без ограничений)
+const maxDecimalPowExponent = 1000
+
+// mathFunctions математические функции над float64
+var mathFunctions = map[string]exprFunc{
+	"pow":   fnPow,
+	"min":   fnMin,
+	"max":   fnMax,
+	"abs":   fnAbs,
+	"floor": fnFloor,
+	"ceil":  fnCeil,
+	"round": fnRound,
+	"sqrt":  fnSqrt,
+}
+
+// decimalFunctions те же функции для режима decimal
+var decimalFunctions = map[string]exprFunc{
+	"pow":   fnDecimalPow,
+	"min":   fnDecimalMin,
+	"max":   fnDecimalMax,
+	"abs":   fnDecimalAbs,
+	"floor": fnDecimalFloor,
+	"ceil":  fnDecimalCeil,
+	"round": fnDecimalRound,
+	"sqrt":  fnSqrt,
+}
+
+func init() {
+	// Математические функции доступны во всех выражениях (condition, switch...)
+	for name, fn := range mathFunctions {
+		exprFunctions[name] = fn
+	}
+}
+
+// EvaluateNumber вычисляет выражение как формулу и проверяет, что результат - конечное число
+// Возвращает float64, а в режиме decimal - *big.Rat
+func (e *Expression) EvaluateNumber(ctx *ExecutionContext, decimal bool) (interface{}, error) {
+	env := &exprEnv{source: e.source, ctx: ctx, funcs: exprFunctions, decimal: decimal}
+	if decimal {
+		env.funcs = make(map[string]exprFunc, len(exprFunctions))
+		for name, fn := range exprFunctions {
+			env.funcs[name] = fn
+		}
+		for name, fn := range decimalFunctions {
+			env.funcs[name] = fn
+		}
+	}
+
+	value, err := env.eval(e.root)
+	if err != nil {
+		return nil, err
+	}
+
+	if decimal {
+		rat, ok := toRat(value)
+		if !ok {
+			return nil, numberResultError(value)
+		}
+		return rat, nil
+	}
+
+	num, ok := toExprNumber(value)
+	if !ok {
+		return nil, numberResultError(value)
+	}
+	if math.IsNaN(num) || math.IsInf(num, 0) {
+		return nil, numberResultError(num)
+	}
+	return num, nil
+}
+
+// numberResultError объясняет, почему результат формулы - не число
+func numberResultError(value interface{}) error {
+	if f, ok := value.(float64); ok {
+		if math.IsNaN(f) {
+			return fmt.Errorf("result is NaN")
+		}
+		if math.IsInf(f, 0) {
+			return fmt.Errorf("result overflow")
+		}
+	}
+	return fmt.Errorf("result is %s, not a number", describeType(value))
+}
+
+// evalDecimal вычисляет + - * / % в режиме decimal
+func (env *exprEnv) evalDecimal(n *binaryNode, left, right interface{}) (interface{}, error) {
+	l, ok := toRat(left)
+	if !ok {
+		return nil, env.errorf(n.pos, "operator '%s': left operand is %s, not a number", n.op, describeType(left))
+	}
+	r, ok := toRat(right)
+	if !ok {
+		return nil, env.errorf(n.pos, "operator '%s': right operand is %s, not a number", n.op, describeType(right))
+	}
+
+	result := new(big.Rat)
+	switch n.op {
+	case "+":
+		return result.Add(l, r), nil
+	case "-":
+		return result.Sub(l, r), nil
+	case "*":
+		return result.Mul(l, r), nil
+	case "/":
+		if r.Sign() == 0 {
+			return nil, env.errorf(n.pos, "division by zero")
+		}
+		return result.Quo(l, r), nil
+	case "%":
+		if r.Sign() == 0 {
+			return nil, env.errorf(n.pos, "modulo by zero")
+		}
+		// l - r * trunc(l / r), знак как у делимого (как math.Mod)
+		quo := new(big.Rat).Quo(l, r)
+		trunc := new(big.Int).Quo(quo.Num(), quo.Denom())
+		return result.Sub(l, new(big.Rat).Mul(r, new(big.Rat).SetInt(trunc))), nil
+	default:
+		return nil, env.errorf(n.pos, "unknown operator '%s'", n.op)
+	}
+}
+
+// toRat приводит число (float64, *big.Rat или строку с числом) к big.Rat
+// float64 берётся по его кратчайшему десятичному представлению: 0.1 -> 1/10
+func toRat(value interface{}) (*big.Rat, bool) {
+	switch v := value.(type) {
+	case *big.Rat:
+		return v, true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetString(strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		return new(big.Rat).SetString(strings.TrimSpace(v))
+	default:
+		return nil, false
+	}
+}
+
+// formatDecimal выводит big.Rat десятичным числом без лишних нулей
+func formatDecimal(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	s := r.FloatString(decimalFractionDigits)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// ===== Функции float64 =====
+
+// checkArgCount проверяет количество аргументов (max < 0 - без ограничения)
+func checkArgCount(args []interface{}, min, max int) error {
+	switch {
+	case min == max && len(args) != min:
+		return fmt.Errorf("expected %d arguments, got %d", min, len(args))
+	case len(args) < min:
+		return fmt.Errorf("expected at least %d arguments, got %d", min, len(args))
+	case max >= 0 && len(args) > max:
+		return fmt.Errorf("expected at most %d arguments, got %d", max, len(args))
+	}
+	return nil
+}
+
+// numberArgs приводит аргументы к числам
+func numberArgs(args []interface{}, min, max int) ([]float64, error) {
+	if err := checkArgCount(args, min, max); err != nil {
+		return nil, err
+	}
+	nums := make([]float64, len(args))
+	for i, arg := range args {
+		num, ok := toExprNumber(arg)
+		if !ok {
+			return nil, fmt.Errorf("argument %d is %s, not a number", i+1, describeType(arg))
+		}
+		nums[i] = num
+	}
+	return nums, nil
+}
+
+func fnPow(args []interface{}) (interface{}, error) {
+	nums, err := numberArgs(args, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	return math.Pow(nums[0], nums[1]), nil
+}
+
+func fnMin(args []interface{}) (interface{}, error) {
+	nums, err := numberArgs(args, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+	result := nums[0]
+	for _, num := range nums[1:] {
+		result = math.Min(result, num)
+	}
+	return result, nil
+}
+
+func fnMax(args []interface{}) (interface{}, error) {
+	nums, err := numberArgs(args, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+	result := nums[0]
+	for _, num := range nums[1:] {
+		result = math.Max(result, num)
+	}
+	return result, nil
+}
+
+func fnAbs(args []interface{}) (interface{}, error) {
+	nums, err := numberArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return math.Abs(nums[0]), nil
+}
+
+func fnFloor(args []interface{}) (interface{}, error) {
+	nums, err := numberArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return math.Floor(nums[0]), nil
+}
+
+func fnCeil(args []interface{}) (interface{}, error) {
+	nums, err := numberArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return math.Ceil(nums[0]), nil
+}
+
+// fnRound round(x) или round(x, знаков) - округление половины от нуля
+func fnRound(args []interface{}) (interface{}, error) {
+	nums, err := numberArgs(args, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(nums) == 1 {
+		return math.Round(nums[0]), nil
+	}
+	scale := math.Pow(10, math.Trunc(nums[1]))
+	return math.Round(nums[0]*scale) / scale, nil
+}
+
+// fnSqrt в режиме decimal тоже вычисляется в float64 (корень почти всегда иррационален)
+func fnSqrt(args []interface{}) (interface{}, error) {
+	nums, err := numberArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if nums[0] < 0 {
+		return nil, fmt.Errorf("negative argument")
+	}
+	return math.Sqrt(nums[0]), nil
+}
+
+// ===== Функции decimal =====
+
+// ratArgs приводит аргументы к big.Rat
+func ratArgs(args []interface{}, min, max int) ([]*big.Rat, error) {
+	if err := checkArgCount(args, min, max); err != nil {
+		return nil, err
+	}
+	rats := make([]*big.Rat, len(args))
+	for i, arg := range args {
+		r, ok := toRat(arg)
+		if !ok {
+			return nil, fmt.Errorf("argument %d is %s, not a number", i+1, describeType(arg))
+		}
+		rats[i] = r
+	}
+	return rats, nil
+}
+
+// ratFloor наибольшее целое <= r (знаменатель big.Rat всегда положительный, Div - евклидово деление)
+func ratFloor(r *big.Rat) *big.Int {
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+func fnDecimalPow(args []interface{}) (interface{}, error) {
+	rats, err := ratArgs(args, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	if !rats[1].IsInt() {
+		return nil, fmt.Errorf("exponent must be an integer in decimal mode")
+	}
+	exp := rats[1].Num()
+	if exp.CmpAbs(big.NewInt(maxDecimalPowExponent)) > 0 {
+		return nil, fmt.Errorf("exponent is too large (max %d)", maxDecimalPowExponent)
+	}
+	base := rats[0]
+	if exp.Sign() < 0 {
+		if base.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		base = new(big.Rat).Inv(base)
+	}
+	n := new(big.Int).Abs(exp)
+	num := new(big.Int).Exp(base.Num(), n, nil)
+	denom := new(big.Int).Exp(base.Denom(), n, nil)
+	return new(big.Rat).SetFrac(num, denom), nil
+}
+
+func fnDecimalMin(args []interface{}) (interface{}, error) {
+	rats, err := ratArgs(args, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+	result := rats[0]
+	for _, r := range rats[1:] {
+		if r.Cmp(result) < 0 {
+			result = r
+		}
+	}
+	return result, nil
+}
+
+func fnDecimalMax(args []interface{}) (interface{}, error) {
+	rats, err := ratArgs(args, 1, -1)
+	if err != nil {
+		return nil, err
+	}
+	result := rats[0]
+	for _, r := range rats[1:] {
+		if r.Cmp(result) > 0 {
+			result = r
+		}
+	}
+	return result, nil
+}
+
+func fnDecimalAbs(args []interface{}) (interface{}, error) {
+	rats, err := ratArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Abs(rats[0]), nil
+}
+
+func fnDecimalFloor(args []interface{}) (interface{}, error) {
+	rats, err := ratArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).SetInt(ratFloor(rats[0])), nil
+}
+
+func fnDecimalCeil(args []interface{}) (interface{}, error) {
+	rats, err := ratArgs(args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	neg := new(big.Rat).Neg(rats[0])
+	return new(big.Rat).SetInt(new(big.Int).Neg(ratFloor(neg))), nil
+}
+
+// fnDecimalRound round(x) или round(x, знаков) - округление половины от нуля, без потерь точности
+func fnDecimalRound(args []interface{}) (interface{}, error) {
+	rats, err := ratArgs(args, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	digits := int64(0)
+	if len(rats) == 2 {
+		if !rats[1].IsInt() {
+			return nil, fmt.Errorf("digits must be an integer")
+		}
+		digits = rats[1].Num().Int64()
+		if digits < -maxDecimalPowExponent || digits > maxDecimalPowExponent {
+			return nil, fmt.Errorf("digits out of range")
+		}
+	}
+
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(abs64(digits)), nil))
+	if digits < 0 {
+		scale.Inv(scale)
+	}
+
+	// |x| * scale + 1/2, округляем вниз и возвращаем знак
+	scaled := new(big.Rat).Mul(new(big.Rat).Abs(rats[0]), scale)
+	scaled.Add(scaled, big.NewRat(1, 2))
+	rounded := new(big.Rat).SetInt(ratFloor(scaled))
+	if rats[0].Sign() < 0 {
+		rounded.Neg(rounded)
+	}
+	return rounded.Quo(rounded, scale), nil
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,237 @@
+package nodes
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testExprContext() *ExecutionContext {
+	return &ExecutionContext{
+		Webhook: map[string]interface{}{
+			"payload": map[string]interface{}{
+				"email": "admin@example.com",
+			},
+		},
+		Steps: map[string]StepOutput{
+			"http_1": {Output: map[string]interface{}{
+				"status": 200,
+				"body": map[string]interface{}{
+					"items": []interface{}{
+						map[string]interface{}{"name": "first"},
+						map[string]interface{}{"name": "second"},
+					},
+				},
+			}},
+		},
+		Variables: map[string]interface{}{
+			"count":   3,
+			"price":   "10.5",
+			"status":  "paid",
+			"blocked": false,
+			"tags":    []interface{}{"a", "b"},
+		},
+	}
+}
+
+func TestExpressionEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		// Приоритет операторов
+		{"multiplication before addition", "2 + 3 * 4", 14.0},
+		{"parentheses", "(2 + 3) * 4", 20.0},
+		{"left associative subtraction", "10 - 4 - 3", 3.0},
+		{"left associative division", "12 / 3 / 2", 2.0},
+		{"modulo with multiplication", "2 * 7 % 4", 2.0},
+		{"unary minus binds tighter", "-2 * 3", -6.0},
+		{"unary minus in subtraction", "5 - -2", 7.0},
+		{"arithmetic before comparison", "1 + 2 > 2", true},
+		{"comparison before equality", "1 < 2 == true", true},
+		{"and before or", "true || false && false", true},
+		{"parentheses around or", "(true || false) && false", false},
+		{"not binds tighter than and", "!false && false", false},
+		{"in before and", "'a' in ['a'] && 1 == 1", true},
+
+		// Литералы
+		{"float", "1.5", 1.5},
+		{"exponent", "2.5e3", 2500.0},
+		{"single quoted string", "'it\\'s'", "it's"},
+		{"double quoted string", `"a\tb"`, "a\tb"},
+		{"null", "null", nil},
+		{"array", "[1, 'a', true]", []interface{}{1.0, "a", true}},
+
+		// Пути
+		{"template reference", "{{steps.http_1.output.status}} == 200", true},
+		{"identifier path", "steps.http_1.output.status + 1", 201.0},
+		{"bare variable name", "count * 2", 6.0},
+		{"numeric path segment", "steps.http_1.output.body.items.1.name", "second"},
+		{"index", "steps.http_1.output.body.items[0].name", "first"},
+		{"string index", "webhook.payload['email']", "admin@example.com"},
+		{"missing path is null", "variables.missing", nil},
+		{"missing path equals null", "variables.missing == null", true},
+		{"missing template is null", "{{missing}} == null", true},
+		{"template in string", "'{{status}}' == 'paid'", true},
+		{"template in string with text", `"status: {{variables.status}}, count: {{count}}"`, "status: paid, count: 3"},
+		{"missing template in string stays as is", "'{{missing}}'", "{{missing}}"},
+
+		// Операторы
+		{"string concatenation", "'total: ' + count", "total: 3"},
+		{"number in string is a number", "variables.price * 2", 21.0},
+		{"numbers compare as numbers", "'10' == 10", true},
+		{"string comparison", "'abc' < 'abd'", true},
+		{"comparison with null is false", "variables.missing < 1", false},
+		{"in array", "status in ['new', 'paid']", true},
+		{"not in array", "!('x' in variables.tags)", true},
+		{"in string", "'min@' in webhook.payload.email", true},
+		{"not equal", "status != 'new'", true},
+		{"truthy and", "count && status", true},
+		{"short circuit and", "false && unknownFn()", false},
+		{"short circuit or", "true || unknownFn()", true},
+
+		// Функции
+		{"contains", "contains(variables.tags, 'b')", true},
+		{"startsWith", "startsWith(webhook.payload.email, 'admin@')", true},
+		{"endsWith", "endsWith(webhook.payload.email, '.org')", false},
+		{"len array", "len(steps.http_1.output.body.items)", 2.0},
+		{"len string", "len('привет')", 6.0},
+		{"lower", "lower('AbC')", "abc"},
+		{"upper", "upper('AbC')", "ABC"},
+		{"trim", "trim('  x  ')", "x"},
+		{"pow", "pow(2, 10)", 1024.0},
+		{"min", "min(3, 1, 2)", 1.0},
+		{"max", "max(3, 1, 2)", 3.0},
+		{"abs", "abs(-4)", 4.0},
+		{"floor", "floor(1.7)", 1.0},
+		{"ceil", "ceil(1.2)", 2.0},
+		{"round", "round(2.345, 2)", 2.35},
+		{"sqrt", "sqrt(16)", 4.0},
+	}
+
+	ctx := testExprContext()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q) error: %v", tt.expr, err)
+			}
+			got, err := expr.Evaluate(ctx)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) error: %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		pos     int
+		message string
+	}{
+		{"single equals", "a = 1", 3, "use '=='"},
+		{"unclosed template", "{{variables.x", 1, "unclosed '{{'"},
+		{"empty template", "{{ }} == 1", 1, "empty '{{}}'"},
+		{"unterminated string", "'abc", 1, "unterminated string"},
+		{"unexpected character", "1 # 2", 3, "unexpected character '#'"},
+		{"missing operand", "1 +", 4, "unexpected"},
+		{"unclosed parenthesis", "(1 + 2", 7, "expected ')'"},
+		{"trailing token", "1 2", 3, "unexpected"},
+		{"position counts characters", "'я' = 1", 5, "use '=='"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseExpression(tt.expr)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseExpression(%q) error = %v, want *ParseError", tt.expr, err)
+			}
+			if parseErr.Pos != tt.pos {
+				t.Errorf("ParseExpression(%q) position = %d, want %d (%v)", tt.expr, parseErr.Pos, tt.pos, err)
+			}
+			if !strings.Contains(parseErr.Message, tt.message) {
+				t.Errorf("ParseExpression(%q) message = %q, want it to contain %q", tt.expr, parseErr.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestExpressionEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		message string
+	}{
+		{"division by zero", "1 / 0", "division by zero"},
+		{"modulo by zero", "5 % 0", "modulo by zero"},
+		{"unknown function", "nope(1)", "unknown function 'nope'"},
+		{"not a number", "status * 2", "left operand is string"},
+		{"negate string", "-status", "cannot negate"},
+		{"wrong argument count", "pow(2)", "pow()"},
+		{"unknown identifier", "paid == status", "unknown identifier 'paid' at position 1"},
+		{"unknown identifier in function", "len(missing)", "unknown identifier 'missing' at position 5"},
+		{"item outside loop", "item.price > 1", "unknown identifier 'item'"},
+	}
+
+	ctx := testExprContext()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q) error: %v", tt.expr, err)
+			}
+			_, err = expr.Evaluate(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Evaluate(%q) error = %v, want it to contain %q", tt.expr, err, tt.message)
+			}
+		})
+	}
+}
+
+func TestTruthy(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{nil, false},
+		{false, false},
+		{true, true},
+		{0.0, false},
+		{0.5, true},
+		{"", false},
+		{"0", true},
+		{[]interface{}{}, false},
+		{[]interface{}{1}, true},
+		{map[string]interface{}{}, false},
+		{map[string]interface{}{"a": 1}, true},
+	}
+
+	for _, tt := range tests {
+		if got := Truthy(tt.value); got != tt.want {
+			t.Errorf("Truthy(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownIdentifierError(t *testing.T) {
+	expr, err := ParseExpression("count > 1 && unknown")
+	if err != nil {
+		t.Fatalf("ParseExpression error: %v", err)
+	}
+	_, err = expr.Evaluate(testExprContext())
+	var identErr *UnknownIdentifierError
+	if !errors.As(err, &identErr) {
+		t.Fatalf("error = %v, want *UnknownIdentifierError", err)
+	}
+	if identErr.Name != "unknown" || identErr.Pos != 14 {
+		t.Errorf("error = %+v, want name 'unknown' at position 14", identErr)
+	}
+}
@@ -0,0 +1,168 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultForeachMaxIterations ограничение количества итераций по умолчанию
+const DefaultForeachMaxIterations = 1000
+
+// Выходы foreach ноды
+const (
+	ForeachBodyHandle = "body" // очередная итерация - в тело цикла
+	ForeachDoneHandle = "done" // элементы закончились
+)
+
+// ForeachConfig конфигурация foreach ноды
+type ForeachConfig struct {
+	Items         interface{} `json:"items"`                    // "{{steps.http_1.output.body.items}}" или массив
+	MaxIterations int         `json:"max_iterations,omitempty"` // ограничение количества итераций
+}
+
+// ForeachHandler обработчик цикла по массиву
+//
+// Тело цикла - подграф, выходящий из handle "body" и возвращающийся ребром обратно в foreach ноду.
+// Каждый заход в ноду продвигает цикл на один элемент, после последнего - выход "done".
+// Прогресс хранится в ExecutionContext.Loops, текущий элемент - в {{item}} и {{index}},
+// элемент конкретного цикла - в {{loop.<id>.item}} и {{loop.<id>.index}}.
+type ForeachHandler struct{}
+
+// NewForeachHandler создаёт новый ForeachHandler
+func NewForeachHandler() *ForeachHandler {
+	return &ForeachHandler{}
+}
+
+// Execute выполняет foreach ноду
+func (h *ForeachHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	if execCtx.Loops == nil {
+		execCtx.Loops = make(map[string]*LoopState)
+	}
+
+	state, running := execCtx.Loops[node.ID]
+	if !running {
+		// Первый заход - инициализируем цикл
+		var config ForeachConfig
+		if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+			errMsg := fmt.Sprintf("failed to parse foreach config: %v", err)
+			return &NodeResult{
+				Status:     StatusFailed,
+				Error:      &errMsg,
+				ExitHandle: "error",
+			}, nil
+		}
+
+		items, err := resolveItems(config.Items, execCtx)
+		if err != nil {
+			errMsg := err.Error()
+			return &NodeResult{
+				Status:     StatusFailed,
+				Error:      &errMsg,
+				ExitHandle: "error",
+			}, nil
+		}
+
+		maxIterations := config.MaxIterations
+		if maxIterations <= 0 {
+			maxIterations = DefaultForeachMaxIterations
+		}
+		limit := len(items)
+		if limit > maxIterations {
+			limit = maxIterations
+		}
+
+		state = &LoopState{
+			Items:  items,
+			Index:  -1,
+			Limit:  limit,
+			Parent: execCtx.Loop,
+		}
+		execCtx.Loops[node.ID] = state
+	}
+
+	state.Index++
+
+	// Очередная итерация
+	if state.Index < state.Limit {
+		index := state.Index
+		execCtx.Loop = node.ID
+		execCtx.Item = state.Items[index]
+		execCtx.Index = &index
+
+		return &NodeResult{
+			Output: map[string]interface{}{
+				"index": index,
+				"item":  state.Items[index],
+				"total": len(state.Items),
+			},
+			Status:     StatusSuccess,
+			ExitHandle: ForeachBodyHandle,
+		}, nil
+	}
+
+	// Цикл завершён - восстанавливаем item/index внешнего цикла (если есть)
+	delete(execCtx.Loops, node.ID)
+	restoreLoop(execCtx, state.Parent)
+
+	return &NodeResult{
+		Output: map[string]interface{}{
+			"iterations": state.Limit,
+			"total":      len(state.Items),
+			"truncated":  state.Limit < len(state.Items),
+		},
+		Status:     StatusSuccess,
+		ExitHandle: ForeachDoneHandle,
+	}, nil
+}
+
+// ResetLoop сбрасывает прогресс foreach ноды nodeID: в цикл зашли заново, а не из его тела
+// (прошлый проход прервался выходом error или условием в теле). Следующий заход начнёт цикл с первого элемента
+func ResetLoop(execCtx *ExecutionContext, nodeID string) {
+	state, ok := execCtx.Loops[nodeID]
+	if !ok {
+		return
+	}
+	delete(execCtx.Loops, nodeID)
+	if execCtx.Loop == nodeID {
+		restoreLoop(execCtx, state.Parent)
+	}
+}
+
+// restoreLoop делает текущим цикл loopID и восстанавливает его item/index ("" - вне цикла)
+func restoreLoop(execCtx *ExecutionContext, loopID string) {
+	execCtx.Loop = loopID
+	execCtx.Item = nil
+	execCtx.Index = nil
+	if parent, ok := execCtx.Loops[loopID]; ok && parent.Index >= 0 && parent.Index < len(parent.Items) {
+		index := parent.Index
+		execCtx.Item = parent.Items[index]
+		execCtx.Index = &index
+	}
+}
+
+// resolveItems получает массив для итерации из конфига
+func resolveItems(source interface{}, execCtx *ExecutionContext) ([]interface{}, error) {
+	value := source
+	if str, ok := source.(string); ok {
+		path := strings.TrimSpace(str)
+		if strings.HasPrefix(path, "{{") && strings.HasSuffix(path, "}}") {
+			path = strings.TrimSpace(path[2 : len(path)-2])
+		}
+		if val, exists := ResolveReference(execCtx, path); exists {
+			value = val
+		} else {
+			return nil, fmt.Errorf("items not found: %s", str)
+		}
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case nil:
+		return []interface{}{}, nil
+	default:
+		return nil, fmt.Errorf("items must be an array, got %T", value)
+	}
+}
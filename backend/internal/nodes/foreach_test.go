@@ -0,0 +1,110 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForeachHandlerIterates(t *testing.T) {
+	execCtx := &ExecutionContext{Variables: map[string]interface{}{
+		"items": []interface{}{"a", "b"},
+	}}
+	config := map[string]interface{}{"items": "{{items}}"}
+
+	for i, want := range []string{"a", "b"} {
+		result := runNode(t, NewForeachHandler(), "foreach", config, execCtx)
+		assertSuccess(t, result)
+		if result.ExitHandle != ForeachBodyHandle {
+			t.Fatalf("iteration %d: exit handle = %q, want %q", i, result.ExitHandle, ForeachBodyHandle)
+		}
+		if got, _ := ResolvePath(execCtx, "item"); got != want {
+			t.Errorf("iteration %d: item = %v, want %v", i, got, want)
+		}
+		if got, _ := ResolvePath(execCtx, "loop.foreach_1.item"); got != want {
+			t.Errorf("iteration %d: loop.foreach_1.item = %v, want %v", i, got, want)
+		}
+		if got, _ := ResolvePath(execCtx, "loop.foreach_1.index"); got != i {
+			t.Errorf("iteration %d: loop.foreach_1.index = %v, want %d", i, got, i)
+		}
+	}
+
+	result := runNode(t, NewForeachHandler(), "foreach", config, execCtx)
+	assertSuccess(t, result)
+	if result.ExitHandle != ForeachDoneHandle {
+		t.Fatalf("exit handle = %q, want %q", result.ExitHandle, ForeachDoneHandle)
+	}
+	if _, ok := ResolvePath(execCtx, "loop.foreach_1.item"); ok {
+		t.Error("loop.foreach_1.item resolved after the loop finished")
+	}
+	if _, ok := ResolvePath(execCtx, "item"); ok {
+		t.Error("item resolved after the loop finished")
+	}
+}
+
+func TestLoopPathPerLoop(t *testing.T) {
+	// Два цикла в параллельных ветках: item указывает на последний начатый, loop.<id> - на свой
+	index := 0
+	execCtx := &ExecutionContext{
+		Loops: map[string]*LoopState{
+			"foreach_1": {Items: []interface{}{"a", "b"}, Index: 1, Limit: 2},
+			"foreach_2": {Items: []interface{}{"x"}, Index: 0, Limit: 1},
+			"foreach_3": {Items: []interface{}{"y"}, Index: -1, Limit: 1},
+		},
+		Loop:  "foreach_2",
+		Item:  "x",
+		Index: &index,
+	}
+
+	tests := []struct {
+		path  string
+		want  interface{}
+		found bool
+	}{
+		{"loop.foreach_1.item", "b", true},
+		{"loop.foreach_1.index", 1, true},
+		{"loop.foreach_1.total", 2, true},
+		{"loop.foreach_2.item", "x", true},
+		{"item", "x", true},
+		{"loop.foreach_3.item", nil, false},
+		{"loop.unknown.item", nil, false},
+	}
+	for _, tt := range tests {
+		got, found := ResolvePath(execCtx, tt.path)
+		if found != tt.found || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ResolvePath(%s) = %v, %v, want %v, %v", tt.path, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestResetLoop(t *testing.T) {
+	// Внутренний цикл прерван на втором элементе, внешний - на первом
+	outerIndex, innerIndex := 0, 1
+	execCtx := &ExecutionContext{
+		Variables: map[string]interface{}{"items": []interface{}{"x", "y", "z"}},
+		Loops: map[string]*LoopState{
+			"outer":     {Items: []interface{}{"o1", "o2"}, Index: outerIndex, Limit: 2},
+			"foreach_1": {Items: []interface{}{"a", "b", "c"}, Index: innerIndex, Limit: 3, Parent: "outer"},
+		},
+		Loop:  "foreach_1",
+		Item:  "b",
+		Index: &innerIndex,
+	}
+
+	ResetLoop(execCtx, "foreach_1")
+	if _, ok := execCtx.Loops["foreach_1"]; ok {
+		t.Fatal("loop state was not removed")
+	}
+	if execCtx.Loop != "outer" || execCtx.Item != "o1" || execCtx.Index == nil || *execCtx.Index != outerIndex {
+		t.Errorf("current loop = %q, item = %v, want outer loop with item o1", execCtx.Loop, execCtx.Item)
+	}
+
+	// Повторный заход начинает цикл с первого элемента заново, внутри внешнего цикла
+	result := runNode(t, NewForeachHandler(), "foreach", map[string]interface{}{"items": "{{items}}"}, execCtx)
+	assertSuccess(t, result)
+	if result.Output["index"] != 0 || result.Output["item"] != "x" {
+		t.Errorf("output = %v, want first item of the new items", result.Output)
+	}
+	if parent := execCtx.Loops["foreach_1"].Parent; parent != "outer" {
+		t.Errorf("parent = %q, want outer", parent)
+	}
+}
@@ -11,6 +11,8 @@ const (
 	StatusSuccess = "success"
 	StatusFailed  = "failed"
 	StatusSleep   = "sleep"
+	StatusWaiting = "waiting" // нода ждёт внешнего события (завершения дочерней схемы)
+	StatusTimeout = "timeout" // нода не уложилась в таймаут (выставляет движок)
 )
 
 // Node представляет ноду в схеме
@@ -25,6 +27,10 @@ type NodeData struct {
 	Type   string          `json:"type"`   // Реальный тип ноды (start, end, log, etc)
 	Label  string          `json:"label"`  // Метка для UI
 	Config json.RawMessage `json:"config"` // Конфигурация ноды
+	// Timeout таймаут выполнения ноды в секундах, 0 - таймаут типа ноды по умолчанию
+	Timeout int `json:"timeout,omitempty"`
+	// Breakpoint в режиме отладки выполнение останавливается перед этой нодой
+	Breakpoint bool `json:"breakpoint,omitempty"`
 }
 
 // NodeResult результат выполнения ноды
@@ -33,8 +39,23 @@ type NodeResult struct {
 	NextNodeID *string                `json:"next_node_id,omitempty"`
 	Status     string                 `json:"status"`
 	Error      *string                `json:"error,omitempty"`
+	ErrorCode  string                 `json:"error_code,omitempty"`    // код ошибки выполнения (error нода), по умолчанию node_failed
+	ErrorData  interface{}            `json:"error_details,omitempty"` // детали ошибки выполнения
 	SleepUntil *time.Time             `json:"sleep_until,omitempty"`
 	ExitHandle string                 `json:"exit_handle,omitempty"` // "success", "error", "true", "false"
+	SubSchema  *SubSchemaCall         `json:"sub_schema,omitempty"`  // запрос на запуск дочерней схемы
+	Join       *JoinSpec              `json:"join,omitempty"`        // нода - точка слияния параллельных веток
+}
+
+// JoinSpec условие срабатывания join ноды (возвращается обработчиком join)
+type JoinSpec struct {
+	Required int `json:"required"` // сколько веток нужно дождаться, 0 - все ветки parallel (без parallel - все входящие)
+}
+
+// SubSchemaCall запрос на запуск дочерней схемы (возвращается вместе со StatusWaiting)
+type SubSchemaCall struct {
+	SchemaID int64                  `json:"schema_id"`
+	Input    map[string]interface{} `json:"input"`
 }
 
 // ExecutionContext контекст выполнения схемы
@@ -44,6 +65,21 @@ type ExecutionContext struct {
 	Execution map[string]interface{} `json:"execution"`
 	Steps     map[string]StepOutput  `json:"steps"`
 	Variables map[string]interface{} `json:"variables"`
+
+	// Состояние циклов foreach (ключ - ID foreach ноды)
+	// Хранится в контексте, поэтому переживает рестарт воркера и sleep внутри тела цикла
+	Loops map[string]*LoopState `json:"loops,omitempty"`
+	Loop  string                `json:"loop,omitempty"`  // ID текущего (самого вложенного) цикла
+	Item  interface{}           `json:"item,omitempty"`  // текущий элемент цикла - {{item}}
+	Index *int                  `json:"index,omitempty"` // индекс текущего элемента - {{index}}
+}
+
+// LoopState прогресс выполнения foreach ноды
+type LoopState struct {
+	Items  []interface{} `json:"items"`
+	Index  int           `json:"index"`            // индекс текущей итерации
+	Limit  int           `json:"limit"`            // сколько итераций будет выполнено
+	Parent string        `json:"parent,omitempty"` // цикл, внутри которого запущен этот
 }
 
 // StepOutput результат выполнения шага
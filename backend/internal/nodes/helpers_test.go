@@ -0,0 +1,59 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// runNode выполняет ноду обработчиком handler
+// config - JSON строкой или значением, которое сериализуется в JSON
+func runNode(t *testing.T, handler NodeHandler, nodeType string, config interface{}, execCtx *ExecutionContext) *NodeResult {
+	t.Helper()
+	var configJSON json.RawMessage
+	switch c := config.(type) {
+	case string:
+		configJSON = json.RawMessage(c)
+	default:
+		data, err := json.Marshal(c)
+		if err != nil {
+			t.Fatalf("marshal config: %v", err)
+		}
+		configJSON = data
+	}
+
+	node := &Node{ID: nodeType + "_1", Data: NodeData{Type: nodeType, Config: configJSON}}
+	result, err := handler.Execute(context.Background(), node, execCtx, nil)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	return result
+}
+
+// assertSuccess проверяет, что нода выполнилась успешно
+func assertSuccess(t *testing.T, result *NodeResult) {
+	t.Helper()
+	if result.Status != StatusSuccess {
+		errMsg := ""
+		if result.Error != nil {
+			errMsg = *result.Error
+		}
+		t.Fatalf("status = %s, error = %q", result.Status, errMsg)
+	}
+}
+
+// assertFailed проверяет, что нода упала с ошибкой, содержащей message, и ушла в выход error
+// (пустой выход движок тоже направляет в error)
+func assertFailed(t *testing.T, result *NodeResult, message string) {
+	t.Helper()
+	if result.Status != StatusFailed {
+		t.Fatalf("status = %s, want %s", result.Status, StatusFailed)
+	}
+	if result.ExitHandle != "" && result.ExitHandle != "error" {
+		t.Errorf("exit handle = %q, want error", result.ExitHandle)
+	}
+	if result.Error == nil || !strings.Contains(*result.Error, message) {
+		t.Errorf("error = %v, want it to contain %q", result.Error, message)
+	}
+}
@@ -3,24 +3,29 @@ package nodes
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 var (
 	// Регулярка для поиска {{variable_name}}
 	varPattern = regexp.MustCompile(`\{\{([^}]+)\}\}`)
+
+	// Строка целиком состоит из одного {{...}}
+	wholePattern = regexp.MustCompile(`^\s*\{\{([^}]+)\}\}\s*$`)
 )
 
-// InterpolateString заменяет {{variable}} на значения из контекста
+// InterpolateString заменяет {{path}} на значения из контекста
+// Путь разрешается через ResolveReference (steps.<id>.output.*, webhook.payload.*, items[0], | default и т.д.)
 func InterpolateString(s string, ctx *ExecutionContext) string {
 	return varPattern.ReplaceAllStringFunc(s, func(match string) string {
-		// Извлекаем имя переменной из {{...}}
-		varName := strings.TrimSpace(match[2 : len(match)-2])
-		
-		// Ищем значение в Variables
-		if val, ok := ctx.Variables[varName]; ok {
-			return fmt.Sprintf("%v", val)
+		// Извлекаем выражение из {{...}}
+		expr := strings.TrimSpace(match[2 : len(match)-2])
+
+		if val, ok := ResolveReference(ctx, expr); ok {
+			return formatValue(val)
 		}
 		
 		// Если не нашли - возвращаем как есть
@@ -29,9 +34,17 @@ func InterpolateString(s string, ctx *ExecutionContext) string {
 }
 
 // InterpolateValue интерполирует значение (может быть строка, map, slice)
+// Строка, состоящая ровно из одного {{expr}}, заменяется самим значением с его типом
+// (число, bool, объект, массив), а не его строковым представлением
 func InterpolateValue(value interface{}, ctx *ExecutionContext) interface{} {
 	switch v := value.(type) {
 	case string:
+		if m := wholePattern.FindStringSubmatch(v); m != nil {
+			if val, ok := ResolveReference(ctx, strings.TrimSpace(m[1])); ok {
+				return val
+			}
+			return v
+		}
 		return InterpolateString(v, ctx)
 		
 	case map[string]interface{}:
@@ -62,34 +75,50 @@ func InterpolateMap(m map[string]string, ctx *ExecutionContext) map[string]strin
 	return result
 }
 
-// ResolveVariableOrValue получает значение - либо из Variables, либо возвращает как есть
+// ResolveVariableOrValue получает значение - либо из контекста, либо возвращает как есть
 // Используется для операндов в math ноде
 func ResolveVariableOrValue(value interface{}, ctx *ExecutionContext) interface{} {
-	// Если это строка - проверяем, не имя ли это переменной
+	// Если это строка - проверяем, не путь ли это к значению
 	if str, ok := value.(string); ok {
 		// Сначала проверяем есть ли {{...}}
 		if strings.Contains(str, "{{") {
-			return InterpolateString(str, ctx)
+			return InterpolateValue(str, ctx)
 		}
 		
-		// Если нет {{...}}, но есть такая переменная - возвращаем её значение
-		if val, exists := ctx.Variables[str]; exists {
+		// Если нет {{...}}, но путь разрешается (имя переменной, steps.*) - возвращаем значение
+		if val, exists := ResolveReference(ctx, str); exists {
 			return val
 		}
 	}
 	return value
 }
 
-// MarshalInterpolated маршалит значение в JSON и интерполирует
+// MarshalInterpolated интерполирует значение и маршалит его в JSON
+// Интерполяция идёт до маршалинга, поэтому подставленные значения всегда корректно экранированы
 func MarshalInterpolated(value interface{}, ctx *ExecutionContext) ([]byte, error) {
-	// Сначала маршалим в JSON
-	jsonBytes, err := json.Marshal(value)
-	if err != nil {
-		return nil, err
+	return json.Marshal(InterpolateValue(value, ctx))
+}
+
+// formatValue представляет значение для подстановки внутрь строки
+// Скаляры - как есть, объекты и массивы - как JSON
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		// Без экспоненты: 1000000, а не 1e+06
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case *big.Rat:
+		return formatDecimal(v)
+	case bool, int, int32, int64:
+		return fmt.Sprintf("%v", v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
 	}
-	
-	// Потом интерполируем строку
-	interpolated := InterpolateString(string(jsonBytes), ctx)
-	
-	return []byte(interpolated), nil
 }
\ No newline at end of file
@@ -0,0 +1,318 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Операции json_transform ноды
+const (
+	JSONTransformGet     = "get"     // перейти по пути внутрь значения
+	JSONTransformPick    = "pick"    // оставить только указанные поля
+	JSONTransformRename  = "rename"  // переименовать поля
+	JSONTransformMap     = "map"     // преобразовать каждый элемент массива по шаблону
+	JSONTransformFilter  = "filter"  // оставить элементы массива, подходящие под условие
+	JSONTransformFlatten = "flatten" // развернуть вложенные массивы или объекты
+	JSONTransformMerge   = "merge"   // слить объекты
+)
+
+// JSONTransformConfig конфигурация json_transform ноды
+type JSONTransformConfig struct {
+	Source         interface{}       `json:"source"`                    // "{{steps.http_1.output.body}}" или значение
+	Operations     []JSONTransformOp `json:"operations"`                // применяются по порядку
+	ResultVariable string            `json:"result_variable,omitempty"` // переменная для результата
+}
+
+// JSONTransformOp одна операция преобразования
+type JSONTransformOp struct {
+	Op        string            `json:"op"`
+	Path      string            `json:"path,omitempty"`      // get: "data.items[0]"
+	Fields    []string          `json:"fields,omitempty"`    // pick: ["id", "name"]
+	Mapping   map[string]string `json:"mapping,omitempty"`   // rename: {"old": "new"}
+	Template  interface{}       `json:"template,omitempty"`  // map: {"id": "{{item.id}}"}
+	Condition string            `json:"condition,omitempty"` // filter: "item.price > 100"
+	Depth     int               `json:"depth,omitempty"`     // flatten массивов: глубина, по умолчанию 1
+	Separator string            `json:"separator,omitempty"` // flatten объектов: разделитель ключей, по умолчанию "."
+	With      interface{}       `json:"with,omitempty"`      // merge: объект или массив объектов
+	Deep      bool              `json:"deep,omitempty"`      // merge: сливать вложенные объекты
+}
+
+// JSONTransformHandler обработчик преобразования данных между шагами
+// Исходные данные из контекста не изменяются: каждая операция создаёт новые объекты
+type JSONTransformHandler struct{}
+
+// NewJSONTransformHandler создаёт новый JSONTransformHandler
+func NewJSONTransformHandler() *JSONTransformHandler {
+	return &JSONTransformHandler{}
+}
+
+// Execute выполняет json_transform ноду
+func (h *JSONTransformHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	var config JSONTransformConfig
+	if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+		errMsg := fmt.Sprintf("failed to parse json_transform config: %v", err)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// 1. Исходное значение (с типом, см. InterpolateValue)
+	value := InterpolateValue(config.Source, execCtx)
+
+	// 2. Применяем операции по порядку
+	for i, op := range config.Operations {
+		var err error
+		value, err = applyJSONTransform(op, value, execCtx)
+		if err != nil {
+			errMsg := fmt.Sprintf("operation %d (%s): %v", i, op.Op, err)
+			return &NodeResult{
+				Status:     StatusFailed,
+				Error:      &errMsg,
+				ExitHandle: "error",
+			}, nil
+		}
+	}
+
+	// 3. Сохраняем результат в переменную если указано
+	if config.ResultVariable != "" {
+		if execCtx.Variables == nil {
+			execCtx.Variables = make(map[string]interface{})
+		}
+		execCtx.Variables[config.ResultVariable] = value
+	}
+
+	return &NodeResult{
+		Output: map[string]interface{}{
+			"result": value,
+		},
+		Status:     StatusSuccess,
+		ExitHandle: "success",
+	}, nil
+}
+
+// applyJSONTransform применяет одну операцию к значению
+func applyJSONTransform(op JSONTransformOp, value interface{}, execCtx *ExecutionContext) (interface{}, error) {
+	switch op.Op {
+	case JSONTransformGet:
+		segments, err := parsePath(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path '%s': %w", op.Path, err)
+		}
+		for _, segment := range segments {
+			var ok bool
+			if segment.isIndex {
+				value, ok = lookupIndex(value, segment.index)
+			} else {
+				value, ok = lookupField(value, segment.key)
+			}
+			if !ok {
+				return nil, nil
+			}
+		}
+		return value, nil
+
+	case JSONTransformPick:
+		if len(op.Fields) == 0 {
+			return nil, fmt.Errorf("fields are required")
+		}
+		return eachObject(value, func(obj map[string]interface{}) map[string]interface{} {
+			result := make(map[string]interface{}, len(op.Fields))
+			for _, field := range op.Fields {
+				if val, ok := obj[field]; ok {
+					result[field] = val
+				}
+			}
+			return result
+		})
+
+	case JSONTransformRename:
+		if len(op.Mapping) == 0 {
+			return nil, fmt.Errorf("mapping is required")
+		}
+		return eachObject(value, func(obj map[string]interface{}) map[string]interface{} {
+			result := make(map[string]interface{}, len(obj))
+			for key, val := range obj {
+				if newKey, ok := op.Mapping[key]; ok {
+					key = newKey
+				}
+				result[key] = val
+			}
+			return result
+		})
+
+	case JSONTransformMap:
+		items, err := transformArray(value)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			result[i] = InterpolateValue(op.Template, itemContext(execCtx, item, i))
+		}
+		return result, nil
+
+	case JSONTransformFilter:
+		items, err := transformArray(value)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := ParseExpression(op.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition: %w", err)
+		}
+		result := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			matched, err := expr.EvaluateBool(itemContext(execCtx, item, i))
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			if matched {
+				result = append(result, item)
+			}
+		}
+		return result, nil
+
+	case JSONTransformFlatten:
+		switch v := value.(type) {
+		case []interface{}:
+			depth := op.Depth
+			if depth <= 0 {
+				depth = 1
+			}
+			return flattenArray(v, depth), nil
+		case map[string]interface{}:
+			separator := op.Separator
+			if separator == "" {
+				separator = "."
+			}
+			result := make(map[string]interface{})
+			flattenObject(v, "", separator, result)
+			return result, nil
+		default:
+			return nil, fmt.Errorf("expected array or object, got %s", describeType(value))
+		}
+
+	case JSONTransformMerge:
+		// Сливаем текущее значение (объект или массив объектов) с with
+		var objects []interface{}
+		if arr, ok := value.([]interface{}); ok {
+			objects = append(objects, arr...)
+		} else {
+			objects = append(objects, value)
+		}
+		if op.With != nil {
+			with := InterpolateValue(op.With, execCtx)
+			if arr, ok := with.([]interface{}); ok {
+				objects = append(objects, arr...)
+			} else {
+				objects = append(objects, with)
+			}
+		}
+
+		result := make(map[string]interface{})
+		for i, item := range objects {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				if item == nil {
+					continue
+				}
+				return nil, fmt.Errorf("item %d: expected object, got %s", i, describeType(item))
+			}
+			mergeObjects(result, obj, op.Deep)
+		}
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("unknown operation")
+	}
+}
+
+// itemContext контекст для элемента массива: {{item}} и {{index}} указывают на элемент
+// Копия контекста - состояние foreach (item/index) не затрагивается
+func itemContext(execCtx *ExecutionContext, item interface{}, index int) *ExecutionContext {
+	itemCtx := *execCtx
+	itemCtx.Item = item
+	itemCtx.Index = &index
+	return &itemCtx
+}
+
+// transformArray проверяет, что значение - массив (null считается пустым массивом)
+func transformArray(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case nil:
+		return []interface{}{}, nil
+	default:
+		return nil, fmt.Errorf("expected array, got %s", describeType(value))
+	}
+}
+
+// eachObject применяет функцию к объекту или к каждому объекту массива
+func eachObject(value interface{}, fn func(map[string]interface{}) map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return fn(v), nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("item %d: expected object, got %s", i, describeType(item))
+			}
+			result[i] = fn(obj)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected object or array of objects, got %s", describeType(value))
+	}
+}
+
+// flattenArray разворачивает вложенные массивы на depth уровней
+func flattenArray(items []interface{}, depth int) []interface{} {
+	result := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if nested, ok := item.([]interface{}); ok && depth > 0 {
+			result = append(result, flattenArray(nested, depth-1)...)
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// flattenObject разворачивает вложенные объекты в плоский: {"a": {"b": 1}} -> {"a.b": 1}
+func flattenObject(obj map[string]interface{}, prefix, separator string, result map[string]interface{}) {
+	for key, val := range obj {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + separator + key
+		}
+		if nested, ok := val.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenObject(nested, fullKey, separator, result)
+			continue
+		}
+		result[fullKey] = val
+	}
+}
+
+// mergeObjects сливает src в dst; при deep вложенные объекты сливаются, а не заменяются
+func mergeObjects(dst, src map[string]interface{}, deep bool) {
+	for key, val := range src {
+		if deep {
+			srcObj, srcIsObj := val.(map[string]interface{})
+			dstObj, dstIsObj := dst[key].(map[string]interface{})
+			if srcIsObj && dstIsObj {
+				merged := make(map[string]interface{}, len(dstObj)+len(srcObj))
+				mergeObjects(merged, dstObj, true)
+				mergeObjects(merged, srcObj, true)
+				dst[key] = merged
+				continue
+			}
+		}
+		dst[key] = val
+	}
+}
@@ -0,0 +1,201 @@
+package nodes
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// testTransformContext контекст с ответом HTTP шага, как его видит json_transform
+func testTransformContext(t *testing.T) *ExecutionContext {
+	t.Helper()
+	var body interface{}
+	err := json.Unmarshal([]byte(`{
+		"data": {
+			"items": [
+				{"id": 1, "name": "Tea", "price": 50, "meta": {"color": "green"}},
+				{"id": 2, "name": "Coffee", "price": 150, "meta": {"color": "black"}},
+				{"id": 3, "name": "Cake", "price": 300, "meta": {"color": "white"}}
+			],
+			"nested": [[1, [2]], [3], 4],
+			"owner": {"name": "Ann", "address": {"city": "Kazan", "zip": "420000"}}
+		}
+	}`), &body)
+	if err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+	return &ExecutionContext{
+		Steps: map[string]StepOutput{
+			"http_1": {Output: map[string]interface{}{"body": body}},
+		},
+		Variables: map[string]interface{}{
+			"min_price": 100,
+			"defaults":  map[string]interface{}{"currency": "RUB", "address": map[string]interface{}{"country": "RU"}},
+		},
+	}
+}
+
+func TestJSONTransformHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			"get",
+			`{"source": "{{steps.http_1.output.body}}", "operations": [{"op": "get", "path": "data.items[1].name"}]}`,
+			`"Coffee"`,
+		},
+		{
+			"get missing path",
+			`{"source": "{{steps.http_1.output.body}}", "operations": [{"op": "get", "path": "data.missing.field"}]}`,
+			`null`,
+		},
+		{
+			"pick object",
+			`{"source": "{{steps.http_1.output.body.data.owner}}", "operations": [{"op": "pick", "fields": ["name", "unknown"]}]}`,
+			`{"name": "Ann"}`,
+		},
+		{
+			"pick array of objects",
+			`{"source": "{{steps.http_1.output.body.data.items}}", "operations": [{"op": "pick", "fields": ["id"]}]}`,
+			`[{"id": 1}, {"id": 2}, {"id": 3}]`,
+		},
+		{
+			"rename",
+			`{"source": "{{steps.http_1.output.body.data.items}}", "operations": [
+				{"op": "pick", "fields": ["id", "name"]},
+				{"op": "rename", "mapping": {"name": "title"}}
+			]}`,
+			`[{"id": 1, "title": "Tea"}, {"id": 2, "title": "Coffee"}, {"id": 3, "title": "Cake"}]`,
+		},
+		{
+			"map keeps value types",
+			`{"source": "{{steps.http_1.output.body.data.items}}", "operations": [
+				{"op": "map", "template": {"id": "{{item.id}}", "label": "{{index}}: {{item.name}}", "color": "{{item.meta.color}}"}}
+			]}`,
+			`[{"id": 1, "label": "0: Tea", "color": "green"}, {"id": 2, "label": "1: Coffee", "color": "black"}, {"id": 3, "label": "2: Cake", "color": "white"}]`,
+		},
+		{
+			"filter",
+			`{"source": "{{steps.http_1.output.body.data.items}}", "operations": [
+				{"op": "filter", "condition": "item.price >= variables.min_price && item.name != 'Cake'"},
+				{"op": "get", "path": "[0].id"}
+			]}`,
+			`2`,
+		},
+		{
+			"filter null source",
+			`{"source": null, "operations": [{"op": "filter", "condition": "true"}]}`,
+			`[]`,
+		},
+		{
+			"flatten array one level",
+			`{"source": "{{steps.http_1.output.body.data.nested}}", "operations": [{"op": "flatten"}]}`,
+			`[1, [2], 3, 4]`,
+		},
+		{
+			"flatten array with depth",
+			`{"source": "{{steps.http_1.output.body.data.nested}}", "operations": [{"op": "flatten", "depth": 2}]}`,
+			`[1, 2, 3, 4]`,
+		},
+		{
+			"flatten object",
+			`{"source": "{{steps.http_1.output.body.data.owner}}", "operations": [{"op": "flatten", "separator": "_"}]}`,
+			`{"name": "Ann", "address_city": "Kazan", "address_zip": "420000"}`,
+		},
+		{
+			"merge",
+			`{"source": "{{steps.http_1.output.body.data.owner}}", "operations": [{"op": "merge", "with": "{{variables.defaults}}"}]}`,
+			`{"name": "Ann", "currency": "RUB", "address": {"country": "RU"}}`,
+		},
+		{
+			"deep merge",
+			`{"source": "{{steps.http_1.output.body.data.owner}}", "operations": [{"op": "merge", "with": "{{variables.defaults}}", "deep": true}]}`,
+			`{"name": "Ann", "currency": "RUB", "address": {"city": "Kazan", "zip": "420000", "country": "RU"}}`,
+		},
+		{
+			"merge array of objects",
+			`{"source": [{"a": 1}, {"b": 2}, null], "operations": [{"op": "merge", "with": [{"a": 3}]}]}`,
+			`{"a": 3, "b": 2}`,
+		},
+		{
+			"no operations",
+			`{"source": {"a": "{{variables.min_price}}"}}`,
+			`{"a": 100}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execCtx := testTransformContext(t)
+			result := runNode(t, NewJSONTransformHandler(), "json_transform", tt.config, execCtx)
+			assertSuccess(t, result)
+
+			// Сравниваем через JSON: так же результат сохраняется в контекст
+			got, err := json.Marshal(result.Output["result"])
+			if err != nil {
+				t.Fatalf("marshal result: %v", err)
+			}
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal(got, &gotValue); err != nil {
+				t.Fatalf("unmarshal result: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+				t.Fatalf("unmarshal want: %v", err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("result = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONTransformHandlerDoesNotModifySource(t *testing.T) {
+	execCtx := testTransformContext(t)
+	config := `{"source": "{{steps.http_1.output.body.data.owner}}", "result_variable": "owner", "operations": [
+		{"op": "rename", "mapping": {"name": "full_name"}},
+		{"op": "merge", "with": {"address": {"city": "Moscow"}}, "deep": true}
+	]}`
+	result := runNode(t, NewJSONTransformHandler(), "json_transform", config, execCtx)
+	assertSuccess(t, result)
+
+	owner, _ := ResolvePath(execCtx, "steps.http_1.output.body.data.owner")
+	want := map[string]interface{}{
+		"name":    "Ann",
+		"address": map[string]interface{}{"city": "Kazan", "zip": "420000"},
+	}
+	if !reflect.DeepEqual(owner, want) {
+		t.Errorf("source changed: %v", owner)
+	}
+	if got := execCtx.Variables["owner"]; !reflect.DeepEqual(got, result.Output["result"]) {
+		t.Errorf("variables.owner = %v, want %v", got, result.Output["result"])
+	}
+}
+
+func TestJSONTransformHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		message string
+	}{
+		{"unknown operation", `{"source": {}, "operations": [{"op": "sort"}]}`, "operation 0 (sort): unknown operation"},
+		{"invalid get path", `{"source": {}, "operations": [{"op": "get", "path": "a[x]"}]}`, "invalid path"},
+		{"pick without fields", `{"source": {}, "operations": [{"op": "pick"}]}`, "fields are required"},
+		{"pick from string", `{"source": "text", "operations": [{"op": "pick", "fields": ["a"]}]}`, "expected object or array of objects"},
+		{"rename without mapping", `{"source": {}, "operations": [{"op": "rename"}]}`, "mapping is required"},
+		{"map over object", `{"source": {"a": 1}, "operations": [{"op": "map", "template": "x"}]}`, "expected array"},
+		{"filter with invalid condition", `{"source": [], "operations": [{"op": "filter", "condition": "item.price >"}]}`, "invalid condition"},
+		{"filter evaluation error", `{"source": [{"a": 1}], "operations": [{"op": "filter", "condition": "item.a / 0"}]}`, "item 0"},
+		{"flatten string", `{"source": "text", "operations": [{"op": "flatten"}]}`, "expected array or object"},
+		{"merge non object", `{"source": [{"a": 1}, 2], "operations": [{"op": "merge"}]}`, "item 1: expected object"},
+		{"second operation fails", `{"source": {"a": 1}, "operations": [{"op": "pick", "fields": ["a"]}, {"op": "map"}]}`, "operation 1 (map)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runNode(t, NewJSONTransformHandler(), "json_transform", tt.config, &ExecutionContext{})
+			assertFailed(t, result, tt.message)
+		})
+	}
+}
@@ -4,15 +4,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strconv"
 )
 
 // MathConfig конфигурация math ноды
+// Либо formula, либо operation + operand1 + operand2 (старый формат)
 type MathConfig struct {
-	Operation      string      `json:"operation"`       // add, subtract, multiply, divide
-	Operand1       interface{} `json:"operand1"`        // ← Исправлено с left
-	Operand2       interface{} `json:"operand2"`        // ← Исправлено с right
-	ResultVariable string      `json:"result_variable"` // ← Добавлено
+	Formula        string      `json:"formula,omitempty"` // Например: "round(({{price}} * {{qty}}) * 1.2, 2)"
+	Operation      string      `json:"operation"`         // add, subtract, multiply, divide, modulo
+	Operand1       interface{} `json:"operand1"`          // ← Исправлено с left
+	Operand2       interface{} `json:"operand2"`          // ← Исправлено с right
+	ResultVariable string      `json:"result_variable"`   // ← Добавлено
+	Decimal        bool        `json:"decimal,omitempty"` // точная десятичная арифметика (для денег)
+}
+
+// mathOperators операции старого формата и соответствующие им операторы формулы
+var mathOperators = map[string]string{
+	"add":      "+",
+	"subtract": "-",
+	"multiply": "*",
+	"divide":   "/",
+	"modulo":   "%",
 }
 
 // MathHandler обработчик математических операций
@@ -34,54 +47,72 @@ func (h *MathHandler) Execute(ctx context.Context, node *Node, execCtx *Executio
 		}, nil
 	}
 
-	// TODO: Интерполировать operand1 и operand2 если это строки с {{...}}
-	// Пока просто пытаемся получить значения из переменных если это строки
-	operand1 := resolveValue(config.Operand1, execCtx)
-	operand2 := resolveValue(config.Operand2, execCtx)
+	output := map[string]interface{}{}
+
+	// 1. Формула: задана явно или собирается из operation + операндов
+	formula := config.Formula
+	if formula == "" {
+		// Операнды: число, имя переменной, путь или строка с {{...}}
+		operand1 := ResolveVariableOrValue(config.Operand1, execCtx)
+		operand2 := ResolveVariableOrValue(config.Operand2, execCtx)
 
-	// Конвертируем в float64
-	left, err := toFloat64(operand1)
+		// Конвертируем в float64
+		left, err := toFloat64(operand1)
+		if err != nil {
+			errMsg := fmt.Sprintf("invalid operand1: %v", err)
+			return &NodeResult{
+				Status: StatusFailed,
+				Error:  &errMsg,
+			}, nil
+		}
+
+		right, err := toFloat64(operand2)
+		if err != nil {
+			errMsg := fmt.Sprintf("invalid operand2: %v", err)
+			return &NodeResult{
+				Status: StatusFailed,
+				Error:  &errMsg,
+			}, nil
+		}
+
+		operator, ok := mathOperators[config.Operation]
+		if !ok {
+			errMsg := fmt.Sprintf("unknown operation: %s", config.Operation)
+			return &NodeResult{
+				Status: StatusFailed,
+				Error:  &errMsg,
+			}, nil
+		}
+
+		formula = fmt.Sprintf("%s %s %s", formatValue(left), operator, formatValue(right))
+		output["operation"] = config.Operation
+		output["operand1"] = left
+		output["operand2"] = right
+	}
+
+	// 2. Вычисляем
+	expr, err := ParseExpression(formula)
 	if err != nil {
-		errMsg := fmt.Sprintf("invalid operand1: %v", err)
+		errMsg := fmt.Sprintf("invalid formula '%s': %v", formula, err)
 		return &NodeResult{
 			Status: StatusFailed,
 			Error:  &errMsg,
 		}, nil
 	}
 
-	right, err := toFloat64(operand2)
+	value, err := expr.EvaluateNumber(execCtx, config.Decimal)
 	if err != nil {
-		errMsg := fmt.Sprintf("invalid operand2: %v", err)
+		errMsg := fmt.Sprintf("failed to evaluate formula '%s': %v", formula, err)
 		return &NodeResult{
 			Status: StatusFailed,
 			Error:  &errMsg,
 		}, nil
 	}
 
-	// Выполняем операцию
-	var result float64
-	switch config.Operation {
-	case "add":
-		result = left + right
-	case "subtract":
-		result = left - right
-	case "multiply":
-		result = left * right
-	case "divide":
-		if right == 0 {
-			errMsg := "division by zero"
-			return &NodeResult{
-				Status: StatusFailed,
-				Error:  &errMsg,
-			}, nil
-		}
-		result = left / right
-	default:
-		errMsg := fmt.Sprintf("unknown operation: %s", config.Operation)
-		return &NodeResult{
-			Status: StatusFailed,
-			Error:  &errMsg,
-		}, nil
+	// В режиме decimal результат - строка, чтобы не потерять точность при сохранении в JSON
+	var result interface{} = value
+	if rat, ok := value.(*big.Rat); ok {
+		result = formatDecimal(rat)
 	}
 
 	// Сохраняем результат в переменную если указано
@@ -92,29 +123,16 @@ func (h *MathHandler) Execute(ctx context.Context, node *Node, execCtx *Executio
 		execCtx.Variables[config.ResultVariable] = result
 	}
 
+	output["result"] = result
+	output["formula"] = formula
+	output["decimal"] = config.Decimal
+
 	return &NodeResult{
-		Output: map[string]interface{}{
-			"result":    result,
-			"operation": config.Operation,
-			"operand1":  left,
-			"operand2":  right,
-		},
+		Output: output,
 		Status: StatusSuccess,
 	}, nil
 }
 
-// resolveValue пытается получить значение из переменных если это строка
-func resolveValue(v interface{}, ctx *ExecutionContext) interface{} {
-	// Если это строка - проверяем, не имя ли это переменной
-	if str, ok := v.(string); ok {
-		// Проверяем есть ли такая переменная
-		if val, exists := ctx.Variables[str]; exists {
-			return val
-		}
-	}
-	return v
-}
-
 // toFloat64 конвертирует interface{} в float64
 func toFloat64(v interface{}) (float64, error) {
 	switch val := v.(type) {
@@ -138,4 +156,4 @@ func toFloat64(v interface{}) (float64, error) {
 	default:
 		return 0, fmt.Errorf("cannot convert %T to float64", v)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,86 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMathHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   interface{}
+	}{
+		{"formula", map[string]interface{}{"formula": "round(({{variables.price}} * {{variables.qty}}) * 1.2, 2)"}, 25.2},
+		{"formula with functions", map[string]interface{}{"formula": "max(abs(-3), pow(2, 3)) - floor(1.9)"}, 7.0},
+		{"float arithmetic", map[string]interface{}{"formula": "0.1 + 0.2"}, 0.30000000000000004},
+		{"float modulo", map[string]interface{}{"formula": "7.5 % 2"}, 1.5},
+		{"float modulo keeps dividend sign", map[string]interface{}{"formula": "-7 % 3"}, -1.0},
+
+		// Точная десятичная арифметика
+		{"decimal addition", map[string]interface{}{"formula": "0.1 + 0.2", "decimal": true}, "0.3"},
+		{"decimal integer result", map[string]interface{}{"formula": "0.5 * 4", "decimal": true}, "2"},
+		{"decimal infinite fraction", map[string]interface{}{"formula": "1 / 3", "decimal": true}, "0.333333333333333333"},
+		{"decimal modulo", map[string]interface{}{"formula": "7.5 % 2", "decimal": true}, "1.5"},
+		{"decimal modulo keeps dividend sign", map[string]interface{}{"formula": "-7 % 3", "decimal": true}, "-1"},
+		{"decimal numbers in strings", map[string]interface{}{"formula": "{{variables.amount}} + {{variables.amount}}", "decimal": true}, "0.2"},
+		{"decimal pow", map[string]interface{}{"formula": "pow(0.1, 3)", "decimal": true}, "0.001"},
+		{"decimal negative pow", map[string]interface{}{"formula": "pow(2, -2)", "decimal": true}, "0.25"},
+		{"decimal round half away from zero", map[string]interface{}{"formula": "round(2.345, 2) + round(-2.5)", "decimal": true}, "-0.65"},
+		{"decimal floor and ceil", map[string]interface{}{"formula": "floor(-1.5) * 10 + ceil(1.1)", "decimal": true}, "-18"},
+		{"decimal min max abs", map[string]interface{}{"formula": "min(0.3, 0.1) + max(0.3, 0.1) + abs(-0.01)", "decimal": true}, "0.41"},
+
+		// Старый формат: operation + operand1 + operand2
+		{"legacy add", map[string]interface{}{"operation": "add", "operand1": 2, "operand2": 3}, 5.0},
+		{"legacy subtract with variable", map[string]interface{}{"operation": "subtract", "operand1": "qty", "operand2": 1}, 1.0},
+		{"legacy multiply", map[string]interface{}{"operation": "multiply", "operand1": "{{variables.price}}", "operand2": 2}, 21.0},
+		{"legacy divide", map[string]interface{}{"operation": "divide", "operand1": 9, "operand2": 2}, 4.5},
+		{"legacy modulo", map[string]interface{}{"operation": "modulo", "operand1": 9, "operand2": 4}, 1.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execCtx := &ExecutionContext{Variables: map[string]interface{}{
+				"price":  10.5,
+				"qty":    2,
+				"amount": "0.1",
+			}}
+			tt.config["result_variable"] = "result"
+			result := runNode(t, NewMathHandler(), "math", tt.config, execCtx)
+			assertSuccess(t, result)
+			if got := result.Output["result"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("result = %#v, want %#v", got, tt.want)
+			}
+			if got := execCtx.Variables["result"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("variables.result = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMathHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		message string
+	}{
+		{"modulo by zero", map[string]interface{}{"formula": "5 % 0"}, "modulo by zero"},
+		{"decimal modulo by zero", map[string]interface{}{"formula": "5 % (1 - 1)", "decimal": true}, "modulo by zero"},
+		{"division by zero", map[string]interface{}{"formula": "5 / 0"}, "division by zero"},
+		{"decimal division by zero", map[string]interface{}{"formula": "5 / 0", "decimal": true}, "division by zero"},
+		{"legacy modulo by zero", map[string]interface{}{"operation": "modulo", "operand1": 5, "operand2": 0}, "modulo by zero"},
+		{"overflow", map[string]interface{}{"formula": "pow(10, 400)"}, "result overflow"},
+		{"not a number", map[string]interface{}{"formula": "'abc'"}, "not a number"},
+		{"decimal fractional exponent", map[string]interface{}{"formula": "pow(2, 0.5)", "decimal": true}, "exponent must be an integer"},
+		{"invalid formula", map[string]interface{}{"formula": "1 +"}, "invalid formula"},
+		{"unknown operation", map[string]interface{}{"operation": "power", "operand1": 2, "operand2": 3}, "unknown operation"},
+		{"invalid operand", map[string]interface{}{"operation": "add", "operand1": "abc", "operand2": 3}, "invalid operand1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runNode(t, NewMathHandler(), "math", tt.config, &ExecutionContext{Variables: map[string]interface{}{}})
+			assertFailed(t, result, tt.message)
+		})
+	}
+}
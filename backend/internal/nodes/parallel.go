@@ -0,0 +1,82 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ParallelHandler обработчик разветвления на параллельные ветки
+// Сами ветки запускает движок: по одному сообщению на каждое исходящее ребро
+type ParallelHandler struct{}
+
+// NewParallelHandler создаёт новый ParallelHandler
+func NewParallelHandler() *ParallelHandler {
+	return &ParallelHandler{}
+}
+
+// Execute выполняет parallel ноду
+func (h *ParallelHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	return &NodeResult{
+		Output: map[string]interface{}{
+			"forked": true,
+		},
+		Status: StatusSuccess,
+	}, nil
+}
+
+// JoinConfig конфигурация join ноды
+type JoinConfig struct {
+	Mode  string `json:"mode"`            // all (по умолчанию) или count
+	Count int    `json:"count,omitempty"` // для mode = count: сколько веток дождаться (N из M)
+}
+
+// JoinHandler обработчик слияния параллельных веток
+// Учёт пришедших веток ведёт движок (в БД), обработчик только сообщает условие срабатывания
+type JoinHandler struct{}
+
+// NewJoinHandler создаёт новый JoinHandler
+func NewJoinHandler() *JoinHandler {
+	return &JoinHandler{}
+}
+
+// Execute выполняет join ноду
+func (h *JoinHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	var config JoinConfig
+	if len(node.Data.Config) > 0 {
+		if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+			errMsg := fmt.Sprintf("failed to parse join config: %v", err)
+			return &NodeResult{
+				Status: StatusFailed,
+				Error:  &errMsg,
+			}, nil
+		}
+	}
+
+	spec := &JoinSpec{}
+	switch config.Mode {
+	case "all", "":
+		// ждём все входящие ветки
+	case "count":
+		if config.Count <= 0 {
+			errMsg := "count must be greater than 0"
+			return &NodeResult{
+				Status: StatusFailed,
+				Error:  &errMsg,
+			}, nil
+		}
+		spec.Required = config.Count
+	default:
+		errMsg := fmt.Sprintf("invalid mode: %s", config.Mode)
+		return &NodeResult{
+			Status: StatusFailed,
+			Error:  &errMsg,
+		}, nil
+	}
+
+	return &NodeResult{
+		Output: map[string]interface{}{},
+		Status: StatusSuccess,
+		Join:   spec,
+	}, nil
+}
@@ -0,0 +1,274 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Разрешение путей внутри {{...}}
+//
+// Путь: корень и поля через точку, элементы массивов - через [N]
+//   {{steps.http_1.output.body.items[0].name}}
+//   {{webhook.payload.user_id}}
+//   {{counter}} - короткая форма для {{variables.counter}}
+//   {{loop.foreach_1.item}} - элемент конкретного цикла (item/index - текущего)
+//
+// После пути могут идти фильтры через |
+//   {{user.name | default:"n/a"}}
+
+// pathSegment один шаг пути: поле объекта или индекс массива
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// ResolveReference вычисляет содержимое {{...}}: путь и необязательные фильтры
+// Возвращает false, если путь не найден и значение по умолчанию не задано
+func ResolveReference(ctx *ExecutionContext, expr string) (interface{}, bool) {
+	parts := splitOutsideQuotes(expr, '|')
+	path := strings.TrimSpace(parts[0])
+
+	var value interface{}
+	var found bool
+	if path != "" {
+		// Переменная с точкой в имени ("user.name") имеет приоритет над путём
+		if val, ok := ctx.Variables[path]; ok {
+			value, found = val, true
+		} else {
+			value, found = ResolvePath(ctx, path)
+		}
+	}
+
+	for _, filter := range parts[1:] {
+		name, arg, _ := strings.Cut(strings.TrimSpace(filter), ":")
+		switch strings.TrimSpace(name) {
+		case "default":
+			if !found || value == nil {
+				value, found = parseLiteral(strings.TrimSpace(arg)), true
+			}
+		default:
+			// Неизвестный фильтр - выражение не вычисляется
+			return nil, false
+		}
+	}
+
+	return value, found
+}
+
+// contextRoots корни путей контекста выполнения, любой другой корень - имя переменной из variables
+var contextRoots = map[string]func(ctx *ExecutionContext) (interface{}, bool){
+	"webhook":   func(ctx *ExecutionContext) (interface{}, bool) { return ctx.Webhook, true },
+	"user":      func(ctx *ExecutionContext) (interface{}, bool) { return ctx.User, true },
+	"execution": func(ctx *ExecutionContext) (interface{}, bool) { return ctx.Execution, true },
+	"variables": func(ctx *ExecutionContext) (interface{}, bool) { return ctx.Variables, true },
+	"steps":     func(ctx *ExecutionContext) (interface{}, bool) { return stepsToMap(ctx.Steps), true },
+	// item и index доступны только внутри тела foreach
+	"item": func(ctx *ExecutionContext) (interface{}, bool) { return ctx.Item, ctx.Index != nil },
+	"index": func(ctx *ExecutionContext) (interface{}, bool) {
+		if ctx.Index == nil {
+			return nil, false
+		}
+		return *ctx.Index, true
+	},
+	// loop.<id>.item/index/total - итерация конкретного цикла, не зависит от текущего
+	"loop": func(ctx *ExecutionContext) (interface{}, bool) { return loopsToMap(ctx.Loops), true },
+}
+
+// IsContextRoot является ли первый сегмент пути корнем контекста (webhook, steps, loop...), а не именем переменной
+func IsContextRoot(name string) bool {
+	_, ok := contextRoots[name]
+	return ok
+}
+
+// ResolvePath ищет значение в контексте выполнения по пути вида "steps.http_1.output.body.items[0]"
+// Корни: webhook, user, execution, steps, variables, item, index, loop (см. contextRoots).
+// Любой другой корень считается именем переменной из variables.
+// Возвращает false, если путь не найден
+func ResolvePath(ctx *ExecutionContext, path string) (interface{}, bool) {
+	segments, err := parsePath(path)
+	if err != nil || len(segments) == 0 || segments[0].isIndex {
+		return nil, false
+	}
+
+	var current interface{}
+	switch root, isRoot := contextRoots[segments[0].key]; {
+	case isRoot:
+		val, ok := root(ctx)
+		if !ok {
+			return nil, false
+		}
+		current = val
+	default:
+		val, ok := ctx.Variables[segments[0].key]
+		if !ok {
+			return nil, false
+		}
+		current = val
+	}
+
+	for _, segment := range segments[1:] {
+		var next interface{}
+		var ok bool
+		if segment.isIndex {
+			next, ok = lookupIndex(current, segment.index)
+		} else {
+			next, ok = lookupField(current, segment.key)
+		}
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+
+	return current, true
+}
+
+// parsePath разбирает путь на сегменты: "a.b[0].c" -> a, b, [0], c
+func parsePath(path string) ([]pathSegment, error) {
+	path = strings.TrimSpace(path)
+	var segments []pathSegment
+
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			if i == 0 || i == len(path)-1 || path[i+1] == '.' || path[i+1] == '[' {
+				return nil, fmt.Errorf("unexpected '.' at %d", i)
+			}
+			i++
+
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' at %d", i)
+			}
+			inner := strings.TrimSpace(path[i+1 : i+end])
+			if unquoted, err := strconv.Unquote(inner); err == nil {
+				// ["ключ с точкой"]
+				segments = append(segments, pathSegment{key: unquoted})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index '%s' at %d", inner, i)
+				}
+				segments = append(segments, pathSegment{index: index, isIndex: true})
+			}
+			i += end + 1
+
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, pathSegment{key: strings.TrimSpace(path[i : i+end])})
+			i += end
+		}
+	}
+
+	return segments, nil
+}
+
+// lookupField возвращает поле объекта по имени
+func lookupField(value interface{}, name string) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		val, ok := v[name]
+		return val, ok
+	case map[string]string:
+		val, ok := v[name]
+		return val, ok
+	case []interface{}:
+		// items.0 - то же что items[0]
+		index, err := strconv.Atoi(name)
+		if err != nil {
+			return nil, false
+		}
+		return lookupIndex(v, index)
+	default:
+		return nil, false
+	}
+}
+
+// lookupIndex возвращает элемент массива по индексу (отрицательный - с конца)
+func lookupIndex(value interface{}, index int) (interface{}, bool) {
+	arr, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	if index < 0 {
+		index += len(arr)
+	}
+	if index < 0 || index >= len(arr) {
+		return nil, false
+	}
+	return arr[index], true
+}
+
+// stepsToMap представляет результаты шагов как обычный map для обхода по пути
+func stepsToMap(steps map[string]StepOutput) map[string]interface{} {
+	result := make(map[string]interface{}, len(steps))
+	for id, step := range steps {
+		result[id] = map[string]interface{}{
+			"output": step.Output,
+		}
+	}
+	return result
+}
+
+// loopsToMap итерации выполняющихся циклов: id -> {item, index, total}
+func loopsToMap(loops map[string]*LoopState) map[string]interface{} {
+	result := make(map[string]interface{}, len(loops))
+	for id, state := range loops {
+		if state.Index < 0 || state.Index >= state.Limit || state.Index >= len(state.Items) {
+			continue
+		}
+		result[id] = map[string]interface{}{
+			"item":  state.Items[state.Index],
+			"index": state.Index,
+			"total": len(state.Items),
+		}
+	}
+	return result
+}
+
+// splitOutsideQuotes делит строку по разделителю, не заходя внутрь кавычек
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseLiteral разбирает аргумент фильтра: строка в кавычках, число, true/false/null
+// Всё остальное считается строкой как есть
+func parseLiteral(s string) interface{} {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(s), &value); err == nil {
+		return value
+	}
+	return s
+}
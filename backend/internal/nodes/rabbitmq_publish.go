@@ -0,0 +1,216 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQPublishConfig конфигурация rabbitmq_publish ноды
+type RabbitMQPublishConfig struct {
+	Queue       string                 `json:"queue"`                  // очередь (routing key для default exchange)
+	Exchange    string                 `json:"exchange,omitempty"`     // exchange, пустой = default exchange
+	RoutingKey  string                 `json:"routing_key,omitempty"`  // routing key, по умолчанию = queue
+	Message     interface{}            `json:"message"`                // строка или объект (будет сериализован в JSON)
+	Headers     map[string]interface{} `json:"headers,omitempty"`      // заголовки сообщения
+	Persistent  *bool                  `json:"persistent,omitempty"`   // по умолчанию true
+	Confirm     bool                   `json:"confirm,omitempty"`      // ждать подтверждения от брокера
+	ContentType string                 `json:"content_type,omitempty"` // по умолчанию определяется по message
+}
+
+// MessagePublisher интерфейс публикации сообщений (реализуется rabbitmq.Publisher)
+type MessagePublisher interface {
+	PublishRaw(ctx context.Context, exchange, routingKey string, msg amqp091.Publishing, confirm bool) (bool, error)
+}
+
+// RabbitMQPublishHandler обработчик ноды публикации в RabbitMQ
+type RabbitMQPublishHandler struct {
+	publisher MessagePublisher
+}
+
+// NewRabbitMQPublishHandler создаёт новый RabbitMQPublishHandler
+func NewRabbitMQPublishHandler(publisher MessagePublisher) *RabbitMQPublishHandler {
+	return &RabbitMQPublishHandler{
+		publisher: publisher,
+	}
+}
+
+// Execute выполняет rabbitmq_publish ноду
+func (h *RabbitMQPublishHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	var config RabbitMQPublishConfig
+	if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+		errMsg := fmt.Sprintf("failed to parse rabbitmq_publish config: %v", err)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// 1. Определяем куда публикуем
+	exchange := InterpolateString(config.Exchange, execCtx)
+	routingKey := InterpolateString(config.RoutingKey, execCtx)
+	if routingKey == "" {
+		routingKey = InterpolateString(config.Queue, execCtx)
+	}
+	if exchange == "" && routingKey == "" {
+		errMsg := "queue or exchange is required"
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// 2. Готовим тело сообщения
+	body, contentType, err := buildMessageBody(config.Message, execCtx)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to build message: %v", err)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+	if config.ContentType != "" {
+		contentType = config.ContentType
+	}
+
+	// 3. Заголовки
+	var headers amqp091.Table
+	if len(config.Headers) > 0 {
+		headers = amqp091.Table{}
+		for k, v := range config.Headers {
+			headers[k] = headerValue(InterpolateValue(v, execCtx))
+		}
+		if err := headers.Validate(); err != nil {
+			errMsg := fmt.Sprintf("invalid headers: %v", err)
+			return &NodeResult{
+				Status:     StatusFailed,
+				Error:      &errMsg,
+				ExitHandle: "error",
+			}, nil
+		}
+	}
+
+	// 4. Режим доставки
+	persistent := true
+	if config.Persistent != nil {
+		persistent = *config.Persistent
+	}
+	deliveryMode := amqp091.Transient
+	if persistent {
+		deliveryMode = amqp091.Persistent
+	}
+
+	messageID := uuid.New().String()
+	publishedAt := time.Now()
+
+	msg := amqp091.Publishing{
+		Headers:      headers,
+		ContentType:  contentType,
+		DeliveryMode: deliveryMode,
+		MessageId:    messageID,
+		Timestamp:    publishedAt,
+		Body:         body,
+	}
+
+	// 5. Публикуем
+	confirmed, err := h.publisher.PublishRaw(ctx, exchange, routingKey, msg, config.Confirm)
+	output := map[string]interface{}{
+		"exchange":     exchange,
+		"routing_key":  routingKey,
+		"message_id":   messageID,
+		"size":         len(body),
+		"persistent":   persistent,
+		"confirmed":    confirmed,
+		"published_at": publishedAt,
+	}
+
+	if err != nil {
+		errMsg := fmt.Sprintf("publish failed: %v", err)
+		output["error"] = err.Error()
+		return &NodeResult{
+			Output:     output,
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	return &NodeResult{
+		Output:     output,
+		Status:     StatusSuccess,
+		ExitHandle: "success",
+	}, nil
+}
+
+// headerValue приводит значение заголовка к типам, которые принимает AMQP таблица:
+// объекты становятся вложенными amqp091.Table, массивы - []interface{} с приведёнными элементами
+// Остальные значения не меняются - неподдерживаемые типы отклонит Table.Validate
+func headerValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, []byte:
+		return v
+	case map[string]interface{}:
+		table := make(amqp091.Table, len(v))
+		for k, item := range v {
+			table[k] = headerValue(item)
+		}
+		return table
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = headerValue(item)
+		}
+		return items
+	}
+
+	// Типизированные map и срезы (map[string]string, []string, ...)
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		table := make(amqp091.Table, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			table[iter.Key().String()] = headerValue(iter.Value().Interface())
+		}
+		return table
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = headerValue(rv.Index(i).Interface())
+		}
+		return items
+	}
+	return value
+}
+
+// buildMessageBody интерполирует сообщение и возвращает тело и content type
+// Строка отправляется как есть, остальное - как JSON
+// ("{{steps.http_1.output.body}}" с объектом внутри тоже уйдёт как JSON)
+func buildMessageBody(message interface{}, execCtx *ExecutionContext) ([]byte, string, error) {
+	if message == nil {
+		return nil, "", fmt.Errorf("message is required")
+	}
+
+	value := InterpolateValue(message, execCtx)
+	if str, ok := value.(string); ok {
+		return []byte(str), "text/plain", nil
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return body, "application/json", nil
+}
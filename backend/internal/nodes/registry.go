@@ -0,0 +1,52 @@
+package nodes
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+)
+
+// OpenDataSources открывает подключения для db_query ноды (DATASOURCE_<NAME>_URL)
+// sql.Open не подключается сразу - недоступная БД не мешает запуску воркера и API
+func OpenDataSources(urls map[string]string) (map[string]*sql.DB, error) {
+	dataSources := make(map[string]*sql.DB, len(urls))
+	for name, url := range urls {
+		db, err := sql.Open("pgx", url)
+		if err != nil {
+			CloseDataSources(dataSources)
+			return nil, fmt.Errorf("failed to open data source %s: %w", name, err)
+		}
+		dataSources[name] = db
+	}
+	return dataSources, nil
+}
+
+// CloseDataSources закрывает подключения, открытые OpenDataSources
+func CloseDataSources(dataSources map[string]*sql.DB) {
+	for _, db := range dataSources {
+		db.Close()
+	}
+}
+
+// NewRunnableRegistry создаёт реестр обработчиков нод, которые можно выполнить и отдельно от схемы
+// Общий для воркера и API (выполнение одной ноды). Ноды управления потоком
+// (sleep, sub_schema, foreach, parallel, join) имеют смысл только внутри выполнения - их регистрирует воркер
+func NewRunnableRegistry(logger *zap.Logger, dataSources map[string]*sql.DB, publisher MessagePublisher) *HandlerRegistry {
+	registry := NewHandlerRegistry()
+	registry.Register("start", NewStartHandler())
+	registry.Register("end", NewEndHandler())
+	registry.Register("error", NewErrorHandler())
+	registry.Register("log", NewLogHandler(logger))
+	registry.Register("variable_set", NewVariableSetHandler())
+	registry.Register("math", NewMathHandler())
+	registry.Register("json_transform", NewJSONTransformHandler())
+	registry.Register("string_ops", NewStringOpsHandler())
+	registry.Register("db_query", NewDBQueryHandler(dataSources))
+	registry.Register("http_request", NewHTTPRequestHandler())
+	registry.Register("condition", NewConditionHandler())
+	registry.Register("switch", NewSwitchHandler())
+	registry.Register("rabbitmq_publish", NewRabbitMQPublishHandler(publisher))
+	return registry
+}
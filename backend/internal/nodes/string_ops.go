@@ -0,0 +1,427 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Операции string_ops ноды
+const (
+	StringOpSplit        = "split"
+	StringOpJoin         = "join"
+	StringOpReplace      = "replace"
+	StringOpRegexMatch   = "regex_match"
+	StringOpRegexReplace = "regex_replace"
+	StringOpUpper        = "upper"
+	StringOpLower        = "lower"
+	StringOpTitle        = "title"
+	StringOpTrim         = "trim"
+	StringOpPad          = "pad"
+	StringOpSubstring    = "substring"
+	StringOpFormat       = "format"
+)
+
+// MaxPadLength максимальная итоговая длина строки для pad (чтобы не раздувать память воркера)
+const MaxPadLength = 10000
+
+// StringOpsConfig конфигурация string_ops ноды
+type StringOpsConfig struct {
+	Input          interface{} `json:"input"`                     // "{{steps.http_1.output.body.text}}"
+	Operations     []StringOp  `json:"operations"`                // применяются по порядку
+	ResultVariable string      `json:"result_variable,omitempty"` // переменная для результата
+}
+
+// StringOp одна операция над строкой
+// Строковые операции над массивом строк (после split) применяются к каждому элементу
+type StringOp struct {
+	Op          string        `json:"op"`
+	Separator   string        `json:"separator,omitempty"`   // split, join
+	Limit       int           `json:"limit,omitempty"`       // split: максимум частей
+	Old         string        `json:"old,omitempty"`         // replace
+	New         string        `json:"new,omitempty"`         // replace
+	Pattern     string        `json:"pattern,omitempty"`     // regex_match, regex_replace
+	Replacement string        `json:"replacement,omitempty"` // regex_replace: "$1", "${name}"
+	Group       string        `json:"group,omitempty"`       // regex_match: вернуть только группу (номер или имя)
+	All         bool          `json:"all,omitempty"`         // regex_match: все совпадения
+	Chars       string        `json:"chars,omitempty"`       // trim: набор символов (по умолчанию пробельные)
+	Side        string        `json:"side,omitempty"`        // trim: left|right|both, pad: left|right
+	Length      int           `json:"length,omitempty"`      // pad: итоговая длина
+	Char        string        `json:"char,omitempty"`        // pad: символ заполнения (по умолчанию пробел)
+	Start       int           `json:"start,omitempty"`       // substring: начало (отрицательное - с конца)
+	End         *int          `json:"end,omitempty"`         // substring: конец не включительно (по умолчанию - до конца)
+	Format      string        `json:"format,omitempty"`      // format: "Заказ %s от %s"
+	Args        []interface{} `json:"args,omitempty"`        // format: аргументы после текущего значения
+}
+
+// StringOpsHandler обработчик цепочки строковых операций
+type StringOpsHandler struct{}
+
+// NewStringOpsHandler создаёт новый StringOpsHandler
+func NewStringOpsHandler() *StringOpsHandler {
+	return &StringOpsHandler{}
+}
+
+// Execute выполняет string_ops ноду
+func (h *StringOpsHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	var config StringOpsConfig
+	if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+		errMsg := fmt.Sprintf("failed to parse string_ops config: %v", err)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// 1. Исходное значение: строка или массив строк, остальное приводится к строке
+	value := InterpolateValue(config.Input, execCtx)
+	if _, isArray := value.([]interface{}); !isArray {
+		if _, isString := value.(string); !isString {
+			value = formatValue(value)
+		}
+	}
+
+	// 2. Применяем операции по порядку
+	for i, op := range config.Operations {
+		var err error
+		value, err = applyStringOp(op, value, execCtx)
+		if err != nil {
+			errMsg := fmt.Sprintf("operation %d (%s): %v", i, op.Op, err)
+			return &NodeResult{
+				Status:     StatusFailed,
+				Error:      &errMsg,
+				ExitHandle: "error",
+			}, nil
+		}
+	}
+
+	// 3. Сохраняем результат в переменную если указано
+	if config.ResultVariable != "" {
+		if execCtx.Variables == nil {
+			execCtx.Variables = make(map[string]interface{})
+		}
+		execCtx.Variables[config.ResultVariable] = value
+	}
+
+	return &NodeResult{
+		Output: map[string]interface{}{
+			"result": value,
+		},
+		Status:     StatusSuccess,
+		ExitHandle: "success",
+	}, nil
+}
+
+// applyStringOp применяет одну операцию к значению
+func applyStringOp(op StringOp, value interface{}, execCtx *ExecutionContext) (interface{}, error) {
+	switch op.Op {
+	case StringOpSplit:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %s", describeType(value))
+		}
+		limit := op.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		parts := strings.SplitN(s, op.Separator, limit)
+		result := make([]interface{}, len(parts))
+		for i, part := range parts {
+			result[i] = part
+		}
+		return result, nil
+
+	case StringOpJoin:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %s", describeType(value))
+		}
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = formatValue(item)
+		}
+		return strings.Join(parts, op.Separator), nil
+
+	case StringOpReplace:
+		if op.Old == "" {
+			return nil, fmt.Errorf("old is required")
+		}
+		return eachString(value, func(s string) (interface{}, error) {
+			return strings.ReplaceAll(s, op.Old, op.New), nil
+		})
+
+	case StringOpRegexMatch:
+		re, err := regexp.Compile(op.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %w", op.Pattern, err)
+		}
+		return eachString(value, func(s string) (interface{}, error) {
+			return regexMatch(re, s, op.Group, op.All)
+		})
+
+	case StringOpRegexReplace:
+		re, err := regexp.Compile(op.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %w", op.Pattern, err)
+		}
+		return eachString(value, func(s string) (interface{}, error) {
+			return re.ReplaceAllString(s, op.Replacement), nil
+		})
+
+	case StringOpUpper:
+		return eachString(value, func(s string) (interface{}, error) {
+			return strings.ToUpper(s), nil
+		})
+
+	case StringOpLower:
+		return eachString(value, func(s string) (interface{}, error) {
+			return strings.ToLower(s), nil
+		})
+
+	case StringOpTitle:
+		return eachString(value, func(s string) (interface{}, error) {
+			return titleCase(s), nil
+		})
+
+	case StringOpTrim:
+		return eachString(value, func(s string) (interface{}, error) {
+			return trimString(s, op.Chars, op.Side)
+		})
+
+	case StringOpPad:
+		if op.Length <= 0 {
+			return nil, fmt.Errorf("length must be greater than 0")
+		}
+		if op.Length > MaxPadLength {
+			return nil, fmt.Errorf("length must not exceed %d", MaxPadLength)
+		}
+		char := op.Char
+		if char == "" {
+			char = " "
+		}
+		if utf8.RuneCountInString(char) != 1 {
+			return nil, fmt.Errorf("char must be a single character")
+		}
+		return eachString(value, func(s string) (interface{}, error) {
+			missing := op.Length - utf8.RuneCountInString(s)
+			if missing <= 0 {
+				return s, nil
+			}
+			padding := strings.Repeat(char, missing)
+			switch op.Side {
+			case "left", "":
+				return padding + s, nil
+			case "right":
+				return s + padding, nil
+			default:
+				return nil, fmt.Errorf("invalid side: %s", op.Side)
+			}
+		})
+
+	case StringOpSubstring:
+		return eachString(value, func(s string) (interface{}, error) {
+			return substring(s, op.Start, op.End), nil
+		})
+
+	case StringOpFormat:
+		if op.Format == "" {
+			return nil, fmt.Errorf("format is required")
+		}
+		// Текущее значение - первый аргумент, затем интерполированные args
+		args := []interface{}{formatArg{value}}
+		for _, arg := range op.Args {
+			args = append(args, formatArg{InterpolateValue(arg, execCtx)})
+		}
+		return fmt.Sprintf(op.Format, args...), nil
+
+	default:
+		return nil, fmt.Errorf("unknown operation")
+	}
+}
+
+// eachString применяет функцию к строке или к каждой строке массива
+func eachString(value interface{}, fn func(string) (interface{}, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("item %d: expected string, got %s", i, describeType(item))
+			}
+			res, err := fn(s)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			result[i] = res
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected string or array of strings, got %s", describeType(value))
+	}
+}
+
+// regexMatch возвращает совпадение с группами
+// Без group: {"matched", "match", "groups", "named"}, с group - только текст группы ("" если нет совпадения)
+func regexMatch(re *regexp.Regexp, s, group string, all bool) (interface{}, error) {
+	groupIndex := -1
+	if group != "" {
+		if n, err := strconv.Atoi(group); err == nil {
+			groupIndex = n
+		} else {
+			groupIndex = re.SubexpIndex(group)
+		}
+		if groupIndex < 0 || groupIndex > re.NumSubexp() {
+			return nil, fmt.Errorf("unknown group: %s", group)
+		}
+	}
+
+	build := func(m []string) interface{} {
+		if groupIndex >= 0 {
+			if m == nil {
+				return ""
+			}
+			return m[groupIndex]
+		}
+		if m == nil {
+			return map[string]interface{}{
+				"matched": false,
+				"match":   "",
+				"groups":  []interface{}{},
+				"named":   map[string]interface{}{},
+			}
+		}
+		groups := make([]interface{}, 0, len(m)-1)
+		for _, g := range m[1:] {
+			groups = append(groups, g)
+		}
+		named := make(map[string]interface{})
+		for i, name := range re.SubexpNames() {
+			if name != "" {
+				named[name] = m[i]
+			}
+		}
+		return map[string]interface{}{
+			"matched": true,
+			"match":   m[0],
+			"groups":  groups,
+			"named":   named,
+		}
+	}
+
+	if all {
+		matches := re.FindAllStringSubmatch(s, -1)
+		result := make([]interface{}, len(matches))
+		for i, m := range matches {
+			result[i] = build(m)
+		}
+		return result, nil
+	}
+
+	return build(re.FindStringSubmatch(s)), nil
+}
+
+// titleCase переводит первую букву каждого слова в верхний регистр, остальные - в нижний
+func titleCase(s string) string {
+	var b strings.Builder
+	wordStart := true
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if wordStart {
+				b.WriteRune(unicode.ToUpper(r))
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			wordStart = false
+			continue
+		}
+		wordStart = true
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// trimString обрезает пробельные символы (или chars) с одной или обеих сторон
+func trimString(s, chars, side string) (string, error) {
+	switch side {
+	case "both", "":
+		if chars == "" {
+			return strings.TrimSpace(s), nil
+		}
+		return strings.Trim(s, chars), nil
+	case "left":
+		if chars == "" {
+			return strings.TrimLeftFunc(s, unicode.IsSpace), nil
+		}
+		return strings.TrimLeft(s, chars), nil
+	case "right":
+		if chars == "" {
+			return strings.TrimRightFunc(s, unicode.IsSpace), nil
+		}
+		return strings.TrimRight(s, chars), nil
+	default:
+		return "", fmt.Errorf("invalid side: %s", side)
+	}
+}
+
+// substring вырезает подстроку по символам (не байтам); отрицательные индексы - с конца
+func substring(s string, start int, end *int) string {
+	runes := []rune(s)
+	n := len(runes)
+
+	clamp := func(i int) int {
+		if i < 0 {
+			i += n
+		}
+		if i < 0 {
+			return 0
+		}
+		if i > n {
+			return n
+		}
+		return i
+	}
+
+	from := clamp(start)
+	to := n
+	if end != nil {
+		to = clamp(*end)
+	}
+	if from >= to {
+		return ""
+	}
+	return string(runes[from:to])
+}
+
+// formatArg аргумент fmt.Sprintf, который форматируется по глаголу:
+// %s/%v - как при интерполяции, %d - как целое, %f/%e/%g - как дробное
+type formatArg struct {
+	value interface{}
+}
+
+// Format реализует fmt.Formatter
+func (a formatArg) Format(f fmt.State, verb rune) {
+	format := fmt.FormatString(f, verb)
+	switch verb {
+	case 'd', 'x', 'X', 'o', 'b', 'c':
+		if num, ok := toExprNumber(normalizeExprValue(a.value)); ok {
+			fmt.Fprintf(f, format, int64(math.Round(num)))
+			return
+		}
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if num, ok := toExprNumber(normalizeExprValue(a.value)); ok {
+			fmt.Fprintf(f, format, num)
+			return
+		}
+	}
+	fmt.Fprintf(f, format, formatValue(a.value))
+}
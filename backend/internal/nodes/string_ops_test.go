@@ -0,0 +1,162 @@
+package nodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testStringOpsContext() *ExecutionContext {
+	return &ExecutionContext{
+		Variables: map[string]interface{}{
+			"csv":    "apple, banana ,cherry",
+			"order":  "A-17",
+			"amount": 1234.5,
+			"count":  7,
+			"name":   "ivan petrov",
+		},
+	}
+}
+
+func TestStringOpsHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   interface{}
+	}{
+		// split / join
+		{"split", `{"input": "a,b,c", "operations": [{"op": "split", "separator": ","}]}`, []interface{}{"a", "b", "c"}},
+		{"split with limit", `{"input": "a,b,c", "operations": [{"op": "split", "separator": ",", "limit": 2}]}`, []interface{}{"a", "b,c"}},
+		{"split empty separator", `{"input": "абв", "operations": [{"op": "split"}]}`, []interface{}{"а", "б", "в"}},
+		{"join", `{"input": "a,b,c", "operations": [{"op": "split", "separator": ","}, {"op": "join", "separator": "|"}]}`, "a|b|c"},
+		{"join formats values", `{"input": [1, true, "x"], "operations": [{"op": "join", "separator": "-"}]}`, "1-true-x"},
+		{
+			"string operations apply to each item",
+			`{"input": "{{variables.csv}}", "operations": [
+				{"op": "split", "separator": ","},
+				{"op": "trim"},
+				{"op": "upper"},
+				{"op": "join", "separator": ";"}
+			]}`,
+			"APPLE;BANANA;CHERRY",
+		},
+
+		// replace
+		{"replace", `{"input": "a-b-c", "operations": [{"op": "replace", "old": "-", "new": "+"}]}`, "a+b+c"},
+		{"replace with empty", `{"input": "a-b-c", "operations": [{"op": "replace", "old": "-"}]}`, "abc"},
+
+		// regex_match
+		{
+			"regex_match",
+			`{"input": "order A-17", "operations": [{"op": "regex_match", "pattern": "(?P<letter>[A-Z])-(\\d+)"}]}`,
+			map[string]interface{}{
+				"matched": true,
+				"match":   "A-17",
+				"groups":  []interface{}{"A", "17"},
+				"named":   map[string]interface{}{"letter": "A"},
+			},
+		},
+		{
+			"regex_match no match",
+			`{"input": "none", "operations": [{"op": "regex_match", "pattern": "\\d+"}]}`,
+			map[string]interface{}{
+				"matched": false,
+				"match":   "",
+				"groups":  []interface{}{},
+				"named":   map[string]interface{}{},
+			},
+		},
+		{"regex_match group number", `{"input": "{{variables.order}}", "operations": [{"op": "regex_match", "pattern": "([A-Z])-(\\d+)", "group": "2"}]}`, "17"},
+		{"regex_match group name", `{"input": "{{variables.order}}", "operations": [{"op": "regex_match", "pattern": "(?P<num>\\d+)", "group": "num"}]}`, "17"},
+		{"regex_match group without match", `{"input": "x", "operations": [{"op": "regex_match", "pattern": "(\\d+)", "group": "1"}]}`, ""},
+		{"regex_match all", `{"input": "a1 b22 c333", "operations": [{"op": "regex_match", "pattern": "\\d+", "group": "0", "all": true}]}`, []interface{}{"1", "22", "333"}},
+
+		// regex_replace
+		{"regex_replace", `{"input": "2024-01-31", "operations": [{"op": "regex_replace", "pattern": "(\\d+)-(\\d+)-(\\d+)", "replacement": "$3.$2.$1"}]}`, "31.01.2024"},
+		{"regex_replace named group", `{"input": "John Smith", "operations": [{"op": "regex_replace", "pattern": "(?P<first>\\w+) (?P<last>\\w+)", "replacement": "${last}, ${first}"}]}`, "Smith, John"},
+
+		// upper / lower / title
+		{"upper", `{"input": "привет", "operations": [{"op": "upper"}]}`, "ПРИВЕТ"},
+		{"lower", `{"input": "HeLLo", "operations": [{"op": "lower"}]}`, "hello"},
+		{"title", `{"input": "{{variables.name}}", "operations": [{"op": "title"}]}`, "Ivan Petrov"},
+		{"title lowers rest of word", `{"input": "hELLO-wORLD 2nd", "operations": [{"op": "title"}]}`, "Hello-World 2nd"},
+
+		// trim
+		{"trim spaces", `{"input": "  x \t\n", "operations": [{"op": "trim"}]}`, "x"},
+		{"trim left", `{"input": "  x  ", "operations": [{"op": "trim", "side": "left"}]}`, "x  "},
+		{"trim right", `{"input": "  x  ", "operations": [{"op": "trim", "side": "right"}]}`, "  x"},
+		{"trim chars", `{"input": "--x--", "operations": [{"op": "trim", "chars": "-"}]}`, "x"},
+		{"trim chars left", `{"input": "00042", "operations": [{"op": "trim", "chars": "0", "side": "left"}]}`, "42"},
+
+		// pad
+		{"pad left by default", `{"input": "{{variables.count}}", "operations": [{"op": "pad", "length": 3, "char": "0"}]}`, "007"},
+		{"pad right", `{"input": "ab", "operations": [{"op": "pad", "length": 4, "side": "right"}]}`, "ab  "},
+		{"pad counts characters", `{"input": "яя", "operations": [{"op": "pad", "length": 3, "char": "*"}]}`, "*яя"},
+		{"pad longer string unchanged", `{"input": "abcdef", "operations": [{"op": "pad", "length": 3}]}`, "abcdef"},
+
+		// substring
+		{"substring", `{"input": "hello world", "operations": [{"op": "substring", "start": 0, "end": 5}]}`, "hello"},
+		{"substring to end", `{"input": "hello world", "operations": [{"op": "substring", "start": 6}]}`, "world"},
+		{"substring negative", `{"input": "hello world", "operations": [{"op": "substring", "start": -5, "end": -1}]}`, "worl"},
+		{"substring by characters", `{"input": "привет", "operations": [{"op": "substring", "start": 1, "end": 3}]}`, "ри"},
+		{"substring out of range", `{"input": "abc", "operations": [{"op": "substring", "start": 2, "end": 1}]}`, ""},
+
+		// format
+		{"format", `{"input": "{{variables.order}}", "operations": [{"op": "format", "format": "Заказ %s на %s", "args": ["{{variables.amount}}"]}]}`, "Заказ A-17 на 1234.5"},
+		{"format number verbs", `{"input": "{{variables.amount}}", "operations": [{"op": "format", "format": "%d / %.2f / %05d", "args": ["{{variables.amount}}", "{{variables.count}}"]}]}`, "1235 / 1234.50 / 00007"},
+		{"format number in string", `{"input": "12.345", "operations": [{"op": "format", "format": "%.1f"}]}`, "12.3"},
+
+		// Приведение входа к строке
+		{"number input", `{"input": "{{variables.count}}", "operations": [{"op": "pad", "length": 2, "char": "0"}]}`, "07"},
+		{"no operations", `{"input": "{{variables.amount}}"}`, "1234.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runNode(t, NewStringOpsHandler(), "string_ops", tt.config, testStringOpsContext())
+			assertSuccess(t, result)
+			if got := result.Output["result"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("result = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringOpsHandlerResultVariable(t *testing.T) {
+	execCtx := testStringOpsContext()
+	result := runNode(t, NewStringOpsHandler(), "string_ops", `{"input": "{{variables.name}}", "operations": [{"op": "upper"}], "result_variable": "upper_name"}`, execCtx)
+	assertSuccess(t, result)
+	if got := execCtx.Variables["upper_name"]; got != "IVAN PETROV" {
+		t.Errorf("variables.upper_name = %#v, want %q", got, "IVAN PETROV")
+	}
+}
+
+func TestStringOpsHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		message string
+	}{
+		{"unknown operation", `{"input": "x", "operations": [{"op": "reverse"}]}`, "operation 0 (reverse): unknown operation"},
+		{"split array", `{"input": "a,b", "operations": [{"op": "split", "separator": ","}, {"op": "split"}]}`, "operation 1 (split): expected string, got array"},
+		{"join string", `{"input": "x", "operations": [{"op": "join"}]}`, "expected array, got string"},
+		{"replace without old", `{"input": "x", "operations": [{"op": "replace", "new": "y"}]}`, "old is required"},
+		{"regex_match invalid pattern", `{"input": "x", "operations": [{"op": "regex_match", "pattern": "("}]}`, "invalid regex"},
+		{"regex_match unknown group", `{"input": "x", "operations": [{"op": "regex_match", "pattern": "(x)", "group": "name"}]}`, "unknown group: name"},
+		{"regex_match group out of range", `{"input": "x", "operations": [{"op": "regex_match", "pattern": "(x)", "group": "2"}]}`, "unknown group: 2"},
+		{"regex_replace invalid pattern", `{"input": "x", "operations": [{"op": "regex_replace", "pattern": "[a-"}]}`, "invalid regex"},
+		{"trim invalid side", `{"input": "x", "operations": [{"op": "trim", "side": "middle"}]}`, "invalid side: middle"},
+		{"pad without length", `{"input": "x", "operations": [{"op": "pad"}]}`, "length must be greater than 0"},
+		{"pad too long", `{"input": "x", "operations": [{"op": "pad", "length": 10001}]}`, "length must not exceed 10000"},
+		{"pad multi character", `{"input": "x", "operations": [{"op": "pad", "length": 3, "char": "ab"}]}`, "char must be a single character"},
+		{"pad invalid side", `{"input": "x", "operations": [{"op": "pad", "length": 3, "side": "center"}]}`, "invalid side: center"},
+		{"format without format", `{"input": "x", "operations": [{"op": "format"}]}`, "format is required"},
+		{"non string item", `{"input": ["a", 1], "operations": [{"op": "upper"}]}`, "item 1: expected string, got number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runNode(t, NewStringOpsHandler(), "string_ops", tt.config, &ExecutionContext{})
+			assertFailed(t, result, tt.message)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultSubSchemaMaxDepth максимальная глубина вложенности вызовов схем по умолчанию
+const DefaultSubSchemaMaxDepth = 10
+
+// SubSchemaConfig конфигурация sub_schema ноды
+type SubSchemaConfig struct {
+	SchemaID     int64                  `json:"schema_id"`               // ID вызываемой схемы
+	InputMapping map[string]interface{} `json:"input_mapping,omitempty"` // переменные дочерней схемы
+}
+
+// SubSchemaHandler обработчик вызова другой схемы
+// Сам обработчик только готовит запрос, дочернее выполнение создаёт движок
+type SubSchemaHandler struct {
+	maxDepth int
+}
+
+// NewSubSchemaHandler создаёт новый SubSchemaHandler
+func NewSubSchemaHandler(maxDepth int) *SubSchemaHandler {
+	if maxDepth <= 0 {
+		maxDepth = DefaultSubSchemaMaxDepth
+	}
+	return &SubSchemaHandler{
+		maxDepth: maxDepth,
+	}
+}
+
+// Execute выполняет sub_schema ноду
+func (h *SubSchemaHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	var config SubSchemaConfig
+	if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+		errMsg := fmt.Sprintf("failed to parse sub_schema config: %v", err)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	if config.SchemaID <= 0 {
+		errMsg := "schema_id is required"
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// Защита от бесконечной рекурсии (схема вызывает сама себя и т.п.)
+	depth := 0
+	if d, ok := execCtx.Execution["depth"]; ok {
+		if f, err := toFloat64(d); err == nil {
+			depth = int(f)
+		}
+	}
+	if depth+1 > h.maxDepth {
+		errMsg := fmt.Sprintf("sub schema depth limit exceeded: %d", h.maxDepth)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// Интерполируем входные параметры
+	input := make(map[string]interface{}, len(config.InputMapping))
+	for k, v := range config.InputMapping {
+		input[k] = InterpolateValue(v, execCtx)
+	}
+
+	return &NodeResult{
+		Output: map[string]interface{}{
+			"schema_id": config.SchemaID,
+			"input":     input,
+		},
+		Status: StatusWaiting,
+		SubSchema: &SubSchemaCall{
+			SchemaID: config.SchemaID,
+			Input:    input,
+		},
+	}, nil
+}
@@ -0,0 +1,148 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Типы проверок для case в switch ноде
+const (
+	SwitchCaseEquals = "equals"
+	SwitchCaseRegex  = "regex"
+	SwitchCaseRange  = "range"
+)
+
+// SwitchDefaultHandle выход switch ноды, если ни один case не подошёл
+const SwitchDefaultHandle = "default"
+
+// SwitchConfig конфигурация switch ноды
+type SwitchConfig struct {
+	Expression string       `json:"expression"` // Например: "{{variables.status}}"
+	Cases      []SwitchCase `json:"cases"`      // Проверяются по порядку, побеждает первый подошедший
+}
+
+// SwitchCase одна ветка switch ноды
+type SwitchCase struct {
+	Handle  string      `json:"handle"`            // sourceHandle ребра для этой ветки
+	Type    string      `json:"type"`              // equals, regex, range
+	Value   interface{} `json:"value,omitempty"`   // для equals
+	Pattern string      `json:"pattern,omitempty"` // для regex
+	Min     *float64    `json:"min,omitempty"`     // для range (включительно)
+	Max     *float64    `json:"max,omitempty"`     // для range (не включительно)
+}
+
+// SwitchCaseHandle выход case с индексом index: handle, а если он не задан - case_<index>
+func SwitchCaseHandle(c SwitchCase, index int) string {
+	if c.Handle == "" {
+		return fmt.Sprintf("case_%d", index)
+	}
+	return c.Handle
+}
+
+// SwitchHandler обработчик ветвления по N именованным выходам
+type SwitchHandler struct{}
+
+// NewSwitchHandler создаёт новый SwitchHandler
+func NewSwitchHandler() *SwitchHandler {
+	return &SwitchHandler{}
+}
+
+// Execute выполняет switch ноду
+func (h *SwitchHandler) Execute(ctx context.Context, node *Node, execCtx *ExecutionContext, preNextIdNode *string) (*NodeResult, error) {
+	var config SwitchConfig
+	if err := json.Unmarshal(node.Data.Config, &config); err != nil {
+		errMsg := fmt.Sprintf("failed to parse switch config: %v", err)
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	if config.Expression == "" {
+		errMsg := "expression is required"
+		return &NodeResult{
+			Status:     StatusFailed,
+			Error:      &errMsg,
+			ExitHandle: "error",
+		}, nil
+	}
+
+	// 1. Вычисляем значение выражения
+	value := strings.TrimSpace(InterpolateString(config.Expression, execCtx))
+
+	// 2. Ищем первый подходящий case
+	exitHandle := SwitchDefaultHandle
+	matchedIndex := -1
+	for i, c := range config.Cases {
+		matched, err := matchSwitchCase(c, value, execCtx)
+		if err != nil {
+			errMsg := fmt.Sprintf("case %d: %v", i, err)
+			return &NodeResult{
+				Status:     StatusFailed,
+				Error:      &errMsg,
+				ExitHandle: "error",
+			}, nil
+		}
+		if matched {
+			exitHandle = SwitchCaseHandle(c, i)
+			matchedIndex = i
+			break
+		}
+	}
+
+	return &NodeResult{
+		Output: map[string]interface{}{
+			"expression": config.Expression,
+			"value":      value,
+			"handle":     exitHandle,
+			"case_index": matchedIndex,
+		},
+		Status:     StatusSuccess,
+		ExitHandle: exitHandle,
+	}, nil
+}
+
+// matchSwitchCase проверяет подходит ли значение под case
+func matchSwitchCase(c SwitchCase, value string, execCtx *ExecutionContext) (bool, error) {
+	switch c.Type {
+	case SwitchCaseEquals, "":
+		expected := formatValue(InterpolateValue(c.Value, execCtx))
+		// Числа сравниваем как числа (чтобы "10" == "10.0")
+		leftNum, leftIsNum := parseNumber(value)
+		rightNum, rightIsNum := parseNumber(expected)
+		if leftIsNum && rightIsNum {
+			return leftNum == rightNum, nil
+		}
+		return value == expected, nil
+
+	case SwitchCaseRegex:
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex '%s': %w", c.Pattern, err)
+		}
+		return re.MatchString(value), nil
+
+	case SwitchCaseRange:
+		if c.Min == nil && c.Max == nil {
+			return false, fmt.Errorf("range requires min or max")
+		}
+		num, ok := parseNumber(value)
+		if !ok {
+			return false, nil // не число - в диапазон не попадает
+		}
+		if c.Min != nil && num < *c.Min {
+			return false, nil
+		}
+		if c.Max != nil && num >= *c.Max {
+			return false, nil
+		}
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("unknown case type: %s", c.Type)
+	}
+}
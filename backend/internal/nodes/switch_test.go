@@ -0,0 +1,98 @@
+package nodes
+
+import "testing"
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestSwitchHandler(t *testing.T) {
+	cases := []SwitchCase{
+		{Handle: "paid", Type: SwitchCaseEquals, Value: "paid"},
+		{Handle: "failed", Type: SwitchCaseRegex, Pattern: "^(error|failed)"},
+		{Handle: "small", Type: SwitchCaseRange, Min: floatPtr(0), Max: floatPtr(100)},
+		{Handle: "large", Type: SwitchCaseRange, Min: floatPtr(100)},
+		{Handle: "negative", Type: SwitchCaseRange, Max: floatPtr(0)},
+		{Type: SwitchCaseEquals, Value: "{{variables.expected}}"},
+	}
+
+	tests := []struct {
+		name   string
+		value  interface{}
+		handle string
+		index  int
+	}{
+		{"equals", "paid", "paid", 0},
+		{"regex prefix", "failed_payment", "failed", 1},
+		{"regex alternative", "error", "failed", 1},
+		{"regex does not match in the middle", "payment_failed", "default", -1},
+		{"range includes min", 0, "small", 2},
+		{"range fraction", 99.5, "small", 2},
+		{"range excludes max", 100, "large", 3},
+		{"open range without max", 1e9, "large", 3},
+		{"open range without min", -5, "negative", 4},
+		{"number in string", "42", "small", 2},
+		{"interpolated value without handle", "custom", "case_5", 5},
+		{"no match", "unknown", "default", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execCtx := &ExecutionContext{Variables: map[string]interface{}{
+				"status":   tt.value,
+				"expected": "custom",
+			}}
+			result := runNode(t, NewSwitchHandler(), "switch", SwitchConfig{Expression: "{{variables.status}}", Cases: cases}, execCtx)
+			assertSuccess(t, result)
+			if result.ExitHandle != tt.handle {
+				t.Errorf("exit handle = %q, want %q", result.ExitHandle, tt.handle)
+			}
+			if got := result.Output["case_index"]; got != tt.index {
+				t.Errorf("case_index = %v, want %d", got, tt.index)
+			}
+		})
+	}
+}
+
+func TestSwitchHandlerFirstMatchWins(t *testing.T) {
+	config := SwitchConfig{
+		Expression: "{{variables.amount}}",
+		Cases: []SwitchCase{
+			{Handle: "any", Type: SwitchCaseRegex, Pattern: "^[0-9]+$"},
+			{Handle: "small", Type: SwitchCaseRange, Max: floatPtr(100)},
+		},
+	}
+	result := runNode(t, NewSwitchHandler(), "switch", config, &ExecutionContext{Variables: map[string]interface{}{"amount": 10}})
+	if result.ExitHandle != "any" {
+		t.Errorf("exit handle = %q, want %q", result.ExitHandle, "any")
+	}
+}
+
+func TestSwitchHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SwitchConfig
+		message string
+	}{
+		{"missing expression", SwitchConfig{}, "expression is required"},
+		{"invalid regex", SwitchConfig{Expression: "x", Cases: []SwitchCase{{Type: SwitchCaseRegex, Pattern: "("}}}, "invalid regex"},
+		{"range without bounds", SwitchConfig{Expression: "1", Cases: []SwitchCase{{Type: SwitchCaseRange}}}, "range requires min or max"},
+		{"unknown case type", SwitchConfig{Expression: "x", Cases: []SwitchCase{{Type: "like"}}}, "unknown case type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runNode(t, NewSwitchHandler(), "switch", tt.config, &ExecutionContext{})
+			assertFailed(t, result, tt.message)
+		})
+	}
+}
+
+func TestSwitchCaseHandle(t *testing.T) {
+	if got := SwitchCaseHandle(SwitchCase{Handle: "paid"}, 3); got != "paid" {
+		t.Errorf("SwitchCaseHandle with handle = %q, want %q", got, "paid")
+	}
+	if got := SwitchCaseHandle(SwitchCase{}, 3); got != "case_3" {
+		t.Errorf("SwitchCaseHandle without handle = %q, want %q", got, "case_3")
+	}
+}
@@ -39,8 +39,8 @@ func (h *VariableSetHandler) Execute(ctx context.Context, node *Node, execCtx *E
 		}, nil
 	}
 
-	// TODO: Интерполировать значение если это строка с {{...}}
-	value := config.Value // ← Исправлено с VariableValue
+	// Интерполируем {{...}} в значении (строки, вложенные объекты и массивы)
+	value := InterpolateValue(config.Value, execCtx)
 
 	// Устанавливаем переменную в контекст
 	if execCtx.Variables == nil {
@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/piplexa/algomap/internal/domain"
 	"go.uber.org/zap"
 )
@@ -26,7 +28,8 @@ func NewExecutionRepository(db *DB, logger *zap.Logger) *ExecutionRepository {
 }
 
 // Create создаёт новое выполнение схемы
-func (r *ExecutionRepository) Create(ctx context.Context, req *domain.CreateExecutionRequest, createdBy int64) (*domain.Execution, error) {
+// schemaVersion - версия схемы, по которой будет идти выполнение (движок читает definition этой версии)
+func (r *ExecutionRepository) Create(ctx context.Context, req *domain.CreateExecutionRequest, createdBy int64, schemaVersion int) (*domain.Execution, error) {
 	// Генерируем UUID
 	executionID := uuid.New()
 
@@ -38,13 +41,22 @@ func (r *ExecutionRepository) Create(ctx context.Context, req *domain.CreateExec
 		payloadJSON = json.RawMessage("{}")
 	}
 
+	// Пошаговая отладка: воркер остановится уже перед стартовой нодой
+	var control *string
+	if req.DebugMode && req.DebugStep {
+		step := domain.ExecutionControlStep
+		control = &step
+	}
+
 	query := `
 		INSERT INTO main.executions (
 			id, schema_id, id_status, id_trigger_type, 
-			trigger_payload, created_by, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, schema_id, id_status, id_trigger_type, trigger_payload, 
-		          current_step_id, started_at, finished_at, created_at, created_by, error
+			trigger_payload, created_by, created_at, schema_version,
+			debug_mode, control
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, schema_id, schema_version, id_status, id_trigger_type, trigger_payload, 
+		          current_step_id, started_at, finished_at, created_at, created_by, error,
+		          debug_mode, control
 	`
 
 	var exec domain.Execution
@@ -58,9 +70,13 @@ func (r *ExecutionRepository) Create(ctx context.Context, req *domain.CreateExec
 		payloadJSON,
 		createdBy,
 		time.Now().UTC(),
+		schemaVersion,
+		req.DebugMode,
+		control,
 	).Scan(
 		&exec.ID,
 		&exec.SchemaID,
+		&exec.SchemaVersion,
 		&exec.StatusID,
 		&exec.TriggerTypeID,
 		&exec.TriggerPayload,
@@ -70,6 +86,8 @@ func (r *ExecutionRepository) Create(ctx context.Context, req *domain.CreateExec
 		&exec.CreatedAt,
 		&exec.CreatedBy,
 		&exec.Error,
+		&exec.DebugMode,
+		&exec.Control,
 	)
 
 	if err != nil {
@@ -98,8 +116,10 @@ func (r *ExecutionRepository) GetByID(ctx context.Context, id string) (*domain.E
 
 	query := `
 		SELECT 
-			id, schema_id, id_status, id_trigger_type, trigger_payload,
-			current_step_id, started_at, finished_at, created_at, created_by, error
+			id, schema_id, schema_version, id_status, id_trigger_type, trigger_payload,
+			current_step_id, started_at, finished_at, created_at, created_by, error, output,
+			parent_execution_id, parent_node_id, depth, control, paused_nodes, debug_mode,
+			forked_from_execution_id, forked_from_step_id
 		FROM main.executions
 		WHERE id = $1
 	`
@@ -108,6 +128,7 @@ func (r *ExecutionRepository) GetByID(ctx context.Context, id string) (*domain.E
 	err = r.db.Pool.QueryRow(ctx, query, executionID).Scan(
 		&exec.ID,
 		&exec.SchemaID,
+		&exec.SchemaVersion,
 		&exec.StatusID,
 		&exec.TriggerTypeID,
 		&exec.TriggerPayload,
@@ -117,6 +138,15 @@ func (r *ExecutionRepository) GetByID(ctx context.Context, id string) (*domain.E
 		&exec.CreatedAt,
 		&exec.CreatedBy,
 		&exec.Error,
+		&exec.Output,
+		&exec.ParentExecutionID,
+		&exec.ParentNodeID,
+		&exec.Depth,
+		&exec.Control,
+		&exec.PausedNodes,
+		&exec.DebugMode,
+		&exec.ForkedFromExecutionID,
+		&exec.ForkedFromStepID,
 	)
 
 	if err != nil {
@@ -131,7 +161,7 @@ func (r *ExecutionRepository) GetByID(ctx context.Context, id string) (*domain.E
 }
 
 // UpdateStatus обновляет статус execution
-func (r *ExecutionRepository) UpdateStatus(ctx context.Context, id string, status int16, errorMsg string) error {
+func (r *ExecutionRepository) UpdateStatus(ctx context.Context, id string, status int16, execErr *domain.ExecutionError) error {
 	executionID, err := uuid.Parse(id)
 	if err != nil {
 		return fmt.Errorf("invalid execution ID: %w", err)
@@ -149,14 +179,9 @@ func (r *ExecutionRepository) UpdateStatus(ctx context.Context, id string, statu
 		WHERE id = $1
 	`
 
-	var errPtr *string
-	if errorMsg != "" {
-		errPtr = &errorMsg
-	}
+	r.logger.Debug("!!!! обновить ошибку !!!!", zap.Any("executionID", executionID), zap.Any("error", execErr))
 
-	r.logger.Debug("!!!! обновить ошибку !!!!", zap.Any("executionID", executionID),  zap.String("errorMsg", *errPtr))
-
-	result, err := r.db.Pool.Exec(ctx, query, executionID, status, errPtr)
+	result, err := r.db.Pool.Exec(ctx, query, executionID, status, execErr)
 	if err != nil {
 		r.logger.Error("Failed to update execution status",
 			zap.Error(err),
@@ -420,6 +445,39 @@ func (r *ExecutionRepository) DeleteBySchemaID(ctx context.Context, schemaID int
 		return fmt.Errorf("failed to delete execution steps: %w", err)
 	}
 
+	// Удаляем учёт join нод
+	deleteJoinsQuery := `
+		DELETE FROM main.execution_join_arrivals
+		WHERE execution_id IN (
+			SELECT id FROM main.executions
+			WHERE schema_id = $1 AND created_by = $2
+		)
+	`
+	_, err = tx.Exec(ctx, deleteJoinsQuery, schemaID, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete execution join arrivals",
+			zap.Error(err),
+			zap.Int64("schema_id", schemaID),
+		)
+		return fmt.Errorf("failed to delete execution join arrivals: %w", err)
+	}
+
+	deleteFiredQuery := `
+		DELETE FROM main.execution_join_fired
+		WHERE execution_id IN (
+			SELECT id FROM main.executions
+			WHERE schema_id = $1 AND created_by = $2
+		)
+	`
+	_, err = tx.Exec(ctx, deleteFiredQuery, schemaID, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete fired joins",
+			zap.Error(err),
+			zap.Int64("schema_id", schemaID),
+		)
+		return fmt.Errorf("failed to delete fired joins: %w", err)
+	}
+
 	// Удаляем execution_state
 	deleteStateQuery := `
 		DELETE FROM main.execution_state
@@ -470,6 +528,693 @@ func (r *ExecutionRepository) DeleteBySchemaID(ctx context.Context, schemaID int
 	return nil
 }
 
+// GetStepContext возвращает контекст, сохранённый в шаге выполнения пользователя
+func (r *ExecutionRepository) GetStepContext(ctx context.Context, stepID int64, userID int64) (*domain.StepContext, error) {
+	var stepCtx domain.StepContext
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT s.id, s.execution_id::text, e.schema_id, s.node_id, s.context
+		FROM main.execution_steps s
+		JOIN main.executions e ON e.id = s.execution_id
+		WHERE s.id = $1 AND e.created_by = $2
+	`, stepID, userID).Scan(
+		&stepCtx.StepID,
+		&stepCtx.ExecutionID,
+		&stepCtx.SchemaID,
+		&stepCtx.NodeID,
+		&stepCtx.Context,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrStepNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get step context: %w", err)
+	}
+
+	return &stepCtx, nil
+}
+
+// Pause ставит выполнение на паузу
+// Воркер проверяет команду перед каждой нодой: нода, выполняющаяся сейчас, завершится,
+// следующие будут отложены в paused_nodes до Resume. Строка выполнения блокируется,
+// поэтому команда не может вклиниться в середину шага (Engine.Execute держит ту же блокировку)
+func (r *ExecutionRepository) Pause(ctx context.Context, id string, userID int64) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	status, control, _, err := r.lockForControl(ctx, tx, id, userID)
+	if err != nil {
+		return err
+	}
+	if isFinishedStatus(status) {
+		return domain.ErrExecutionFinished
+	}
+	if control != nil && *control == domain.ExecutionControlPause {
+		// Уже на паузе - повторный запрос ничего не меняет
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE main.executions SET control = $2 WHERE id = $1
+	`, id, domain.ExecutionControlPause)
+	if err != nil {
+		return fmt.Errorf("failed to pause execution: %w", err)
+	}
+	if err := r.saveControlStep(ctx, tx, id, domain.ControlActionPause, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Execution paused", zap.String("execution_id", id), zap.Int64("user_id", userID))
+
+	return nil
+}
+
+// Resume снимает паузу и возвращает отложенные сообщения - их нужно опубликовать в очередь заново
+func (r *ExecutionRepository) Resume(ctx context.Context, id string, userID int64) ([]json.RawMessage, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	status, control, pausedNodes, err := r.lockForControl(ctx, tx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if isFinishedStatus(status) {
+		return nil, domain.ErrExecutionFinished
+	}
+	if control == nil || *control != domain.ExecutionControlPause {
+		return nil, domain.ErrExecutionNotPaused
+	}
+
+	var messages []json.RawMessage
+	if len(pausedNodes) > 0 {
+		if err := json.Unmarshal(pausedNodes, &messages); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal paused nodes: %w", err)
+		}
+	}
+
+	// Если были отложенные ноды - выполнение продолжается; если нет (например, ждёт sleep) - статус не меняется
+	_, err = tx.Exec(ctx, `
+		UPDATE main.executions
+		SET control = NULL,
+			paused_nodes = '[]'::jsonb,
+			id_status = CASE WHEN $2 > 0 THEN $3 ELSE id_status END
+		WHERE id = $1
+	`, id, len(messages), domain.ExecutionStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume execution: %w", err)
+	}
+	if err := r.saveControlStep(ctx, tx, id, domain.ControlActionResume, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Execution resumed",
+		zap.String("execution_id", id),
+		zap.Int64("user_id", userID),
+		zap.Int("paused_nodes", len(messages)),
+	)
+
+	return messages, nil
+}
+
+// Stop останавливает выполнение вместе с запущенными из него дочерними выполнениями (sub_schema)
+// Возвращает остановленное выполнение: если оно само дочернее, родителя нужно продолжить
+func (r *ExecutionRepository) Stop(ctx context.Context, id string, userID int64) (*domain.Execution, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	status, _, _, err := r.lockForControl(ctx, tx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if isFinishedStatus(status) {
+		return nil, domain.ErrExecutionFinished
+	}
+
+	stopErr := &domain.ExecutionError{
+		Code:    domain.ErrorCodeStopped,
+		Message: "execution stopped by user",
+	}
+	rows, err := tx.Query(ctx, `
+		WITH RECURSIVE tree AS (
+			SELECT id FROM main.executions WHERE id = $1
+			UNION ALL
+			SELECT c.id FROM main.executions c JOIN tree t ON c.parent_execution_id = t.id
+		)
+		UPDATE main.executions e
+		SET id_status = $2,
+			error = $3,
+			finished_at = NOW(),
+			control = NULL,
+			paused_nodes = '[]'::jsonb
+		FROM tree
+		WHERE e.id = tree.id AND e.id_status NOT IN ($4, $5, $6)
+		RETURNING e.id::text
+	`, id, domain.ExecutionStatusStopped, stopErr,
+		domain.ExecutionStatusCompleted, domain.ExecutionStatusFailed, domain.ExecutionStatusStopped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop execution: %w", err)
+	}
+	var stopped []string
+	for rows.Next() {
+		var stoppedID string
+		if err := rows.Scan(&stoppedID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan stopped execution: %w", err)
+		}
+		stopped = append(stopped, stoppedID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to stop execution: %w", err)
+	}
+
+	for _, stoppedID := range stopped {
+		if err := r.saveControlStep(ctx, tx, stoppedID, domain.ControlActionStop, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Execution stopped",
+		zap.String("execution_id", id),
+		zap.Int64("user_id", userID),
+		zap.Strings("stopped", stopped),
+	)
+
+	return r.GetByID(ctx, id)
+}
+
+// DebugAdvance отпускает ноды, перед которыми остановился отладчик, и возвращает их сообщения -
+// их нужно опубликовать в очередь заново. step = true - остановиться перед следующей нодой,
+// false - выполнять до следующего breakpoint
+func (r *ExecutionRepository) DebugAdvance(ctx context.Context, id string, userID int64, step bool) ([]json.RawMessage, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	pausedNodes, err := r.lockForDebug(ctx, tx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var parked []map[string]interface{}
+	if err := json.Unmarshal(pausedNodes, &parked); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal paused nodes: %w", err)
+	}
+	// Отпущенная нода не должна снова остановиться на своём же breakpoint
+	messages := make([]json.RawMessage, 0, len(parked))
+	for _, msg := range parked {
+		msg["skip_breakpoint"] = true
+		msgJSON, err := json.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal message: %w", err)
+		}
+		messages = append(messages, msgJSON)
+	}
+
+	var control *string
+	action := domain.ControlActionContinue
+	if step {
+		stepControl := domain.ExecutionControlStep
+		control = &stepControl
+		action = domain.ControlActionStep
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE main.executions
+		SET control = $2, paused_nodes = '[]'::jsonb, id_status = $3
+		WHERE id = $1
+	`, id, control, domain.ExecutionStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to advance execution: %w", err)
+	}
+	if err := r.saveControlStep(ctx, tx, id, action, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Debug execution advanced",
+		zap.String("execution_id", id),
+		zap.Int64("user_id", userID),
+		zap.String("action", action),
+		zap.Int("released_nodes", len(messages)),
+	)
+
+	return messages, nil
+}
+
+// GetVariables возвращает переменные выполнения (context.variables)
+func (r *ExecutionRepository) GetVariables(ctx context.Context, id string, userID int64) (json.RawMessage, error) {
+	executionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, domain.ErrExecutionNotFound
+	}
+
+	var variables json.RawMessage
+	err = r.db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(s.context->'variables', '{}'::jsonb)
+		FROM main.executions e
+		LEFT JOIN main.execution_state s ON s.execution_id = e.id
+		WHERE e.id = $1 AND e.created_by = $2
+	`, executionID, userID).Scan(&variables)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrExecutionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variables: %w", err)
+	}
+
+	return variables, nil
+}
+
+// SetVariables заменяет переменные выполнения, остановленного отладчиком
+// Следующая нода увидит новые значения
+func (r *ExecutionRepository) SetVariables(ctx context.Context, id string, userID int64, variables json.RawMessage) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := r.lockForDebug(ctx, tx, id, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE main.execution_state
+		SET context = jsonb_set(context, '{variables}', $2::jsonb), updated_at = NOW()
+		WHERE execution_id = $1
+	`, id, variables)
+	if err != nil {
+		return fmt.Errorf("failed to set variables: %w", err)
+	}
+	err = r.saveControlStepOutput(ctx, tx, id, map[string]interface{}{
+		"action":    domain.ControlActionSetVariables,
+		"user_id":   userID,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Debug variables changed", zap.String("execution_id", id), zap.Int64("user_id", userID))
+
+	return nil
+}
+
+// Retry перезапускает упавшее выполнение с упавшей ноды
+// Контекст восстанавливается из шага, на котором нода упала (если шага нет - из execution_state),
+// переменные из variables заменяют восстановленные. Возвращает ноду и сообщение, которое нужно опубликовать
+func (r *ExecutionRepository) Retry(ctx context.Context, id string, userID int64, variables map[string]interface{}) (string, json.RawMessage, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	status, _, _, err := r.lockForControl(ctx, tx, id, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if status != domain.ExecutionStatusFailed {
+		return "", nil, domain.ErrExecutionNotFailed
+	}
+
+	var schemaID int64
+	var debugMode bool
+	var nodeID string
+	var parentExecutionID *string
+	err = tx.QueryRow(ctx, `
+		SELECT schema_id, debug_mode, COALESCE(error->>'node_id', ''), parent_execution_id::text
+		FROM main.executions
+		WHERE id = $1
+	`, id).Scan(&schemaID, &debugMode, &nodeID, &parentExecutionID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get execution: %w", err)
+	}
+	// Родитель упавшего дочернего выполнения уже продолжил работу по error выходу -
+	// перезапускать нужно родителя
+	if nodeID == "" || parentExecutionID != nil {
+		return "", nil, domain.ErrExecutionNotRetryable
+	}
+
+	// Контекст упавшего шага; ошибки лимитов шага не записывают - тогда текущее состояние
+	var stepID *int64
+	var prevNodeID *string
+	var contextJSON json.RawMessage
+	err = tx.QueryRow(ctx, `
+		SELECT id, prev_node_id, context
+		FROM main.execution_steps
+		WHERE execution_id = $1 AND node_id = $2 AND id_status IN ($3, $4)
+		ORDER BY id DESC
+		LIMIT 1
+	`, id, nodeID, domain.StepStatusFailed, domain.StepStatusTimeout).Scan(&stepID, &prevNodeID, &contextJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = tx.QueryRow(ctx, `
+			SELECT context FROM main.execution_state WHERE execution_id = $1
+		`, id).Scan(&contextJSON)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil, domain.ErrExecutionNotRetryable
+		}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get failed step context: %w", err)
+	}
+
+	if contextJSON, err = patchContext(contextJSON, "", variables); err != nil {
+		return "", nil, err
+	}
+
+	// Падение останавливает все ветки, поэтому продолжается одна - перезапущенная
+	_, err = tx.Exec(ctx, `
+		INSERT INTO main.execution_state (execution_id, current_node_id, context, updated_at, active_branches)
+		VALUES ($1, $2, $3, NOW(), 1)
+		ON CONFLICT (execution_id) DO UPDATE SET
+			current_node_id = EXCLUDED.current_node_id,
+			context = EXCLUDED.context,
+			updated_at = EXCLUDED.updated_at,
+			active_branches = EXCLUDED.active_branches,
+			suspended_branches = '{}'
+	`, id, nodeID, contextJSON)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to restore execution state: %w", err)
+	}
+
+	// Лимиты считаются заново: иначе выполнение, упавшее по лимиту шагов или длительности, сразу упадёт снова
+	_, err = tx.Exec(ctx, `
+		UPDATE main.executions
+		SET id_status = $2, error = NULL, finished_at = NULL, control = NULL, paused_nodes = '[]'::jsonb,
+		    started_at = NOW(), cnt_executed_steps = 0
+		WHERE id = $1
+	`, id, domain.ExecutionStatusRunning)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to retry execution: %w", err)
+	}
+
+	output := map[string]interface{}{
+		"action":  domain.ControlActionRetry,
+		"user_id": userID,
+		"node_id": nodeID,
+		"step_id": stepID,
+	}
+	if len(variables) > 0 {
+		output["variables"] = variables
+	}
+	if err := r.saveControlStepOutput(ctx, tx, id, output); err != nil {
+		return "", nil, err
+	}
+
+	message := map[string]interface{}{
+		"execution_id":    id,
+		"schema_id":       schemaID,
+		"current_node_id": nodeID,
+		"debug_mode":      debugMode,
+	}
+	if prevNodeID != nil {
+		message["prev_node_id"] = *prevNodeID
+	}
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Execution retried",
+		zap.String("execution_id", id),
+		zap.Int64("user_id", userID),
+		zap.String("node_id", nodeID),
+	)
+
+	return nodeID, messageJSON, nil
+}
+
+// Fork создаёт новое выполнение той же схемы и версии из шага stepID выполнения id:
+// начальный контекст - контекст шага, первая нода - next_node_id шага.
+// Возвращает новое выполнение и сообщение, которое нужно опубликовать
+func (r *ExecutionRepository) Fork(ctx context.Context, id string, stepID int64, userID int64, req *domain.ForkExecutionRequest) (*domain.Execution, json.RawMessage, error) {
+	executionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, domain.ErrExecutionNotFound
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var schemaID int64
+	var schemaVersion *int
+	var triggerPayload json.RawMessage
+	var nodeID string
+	var nextNodeID *string
+	var contextJSON json.RawMessage
+	err = tx.QueryRow(ctx, `
+		SELECT e.schema_id, e.schema_version, e.trigger_payload, s.node_id, s.next_node_id, s.context
+		FROM main.execution_steps s
+		JOIN main.executions e ON e.id = s.execution_id
+		WHERE s.id = $1 AND s.execution_id = $2 AND e.created_by = $3
+	`, stepID, executionID, userID).Scan(&schemaID, &schemaVersion, &triggerPayload, &nodeID, &nextNodeID, &contextJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, domain.ErrStepNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get step: %w", err)
+	}
+	if nextNodeID == nil || *nextNodeID == "" {
+		return nil, nil, domain.ErrStepNotForkable
+	}
+
+	forkID := uuid.New()
+	if contextJSON, err = patchContext(contextJSON, forkID.String(), req.Variables); err != nil {
+		return nil, nil, err
+	}
+
+	var control *string
+	if req.DebugMode && req.DebugStep {
+		step := domain.ExecutionControlStep
+		control = &step
+	}
+
+	// Форк - самостоятельное выполнение верхнего уровня, даже если шаг был в дочерней схеме
+	_, err = tx.Exec(ctx, `
+		INSERT INTO main.executions (
+			id, schema_id, schema_version, id_status, id_trigger_type, trigger_payload,
+			created_by, created_at, debug_mode, control,
+			forked_from_execution_id, forked_from_step_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, forkID, schemaID, schemaVersion, domain.ExecutionStatusPending, domain.TriggerTypeManual, triggerPayload,
+		userID, time.Now().UTC(), req.DebugMode, control,
+		executionID, stepID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create fork: %w", err)
+	}
+
+	// Продолжается одна ветка - та, в которую ведёт шаг
+	_, err = tx.Exec(ctx, `
+		INSERT INTO main.execution_state (execution_id, current_node_id, context, updated_at, active_branches)
+		VALUES ($1, $2, $3, NOW(), 1)
+	`, forkID, *nextNodeID, contextJSON)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to save fork state: %w", err)
+	}
+
+	output := map[string]interface{}{
+		"action":                   domain.ControlActionFork,
+		"user_id":                  userID,
+		"forked_from_execution_id": id,
+		"forked_from_step_id":      stepID,
+	}
+	if len(req.Variables) > 0 {
+		output["variables"] = req.Variables
+	}
+	if err := r.saveControlStepOutput(ctx, tx, forkID.String(), output); err != nil {
+		return nil, nil, err
+	}
+
+	messageJSON, err := json.Marshal(map[string]interface{}{
+		"execution_id":    forkID.String(),
+		"schema_id":       schemaID,
+		"current_node_id": *nextNodeID,
+		"debug_mode":      req.DebugMode,
+		"prev_node_id":    nodeID,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Execution forked",
+		zap.String("execution_id", id),
+		zap.Int64("step_id", stepID),
+		zap.String("fork_id", forkID.String()),
+		zap.Int64("user_id", userID),
+	)
+
+	fork, err := r.GetByID(ctx, forkID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fork, messageJSON, nil
+}
+
+// patchContext подменяет в сохранённом контексте ID выполнения (если задан)
+// и значения переданных переменных
+func patchContext(contextJSON json.RawMessage, executionID string, variables map[string]interface{}) (json.RawMessage, error) {
+	if executionID == "" && len(variables) == 0 {
+		return contextJSON, nil
+	}
+
+	execCtx := make(map[string]interface{})
+	if len(contextJSON) > 0 {
+		if err := json.Unmarshal(contextJSON, &execCtx); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal context: %w", err)
+		}
+	}
+
+	if executionID != "" {
+		execution, _ := execCtx["execution"].(map[string]interface{})
+		if execution == nil {
+			execution = make(map[string]interface{})
+		}
+		execution["id"] = executionID
+		execCtx["execution"] = execution
+	}
+
+	if len(variables) > 0 {
+		vars, _ := execCtx["variables"].(map[string]interface{})
+		if vars == nil {
+			vars = make(map[string]interface{})
+		}
+		for name, value := range variables {
+			vars[name] = value
+		}
+		execCtx["variables"] = vars
+	}
+
+	patched, err := json.Marshal(execCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal context: %w", err)
+	}
+	return patched, nil
+}
+
+// lockForDebug блокирует выполнение, остановленное отладчиком, и возвращает отложенные сообщения
+func (r *ExecutionRepository) lockForDebug(ctx context.Context, tx pgx.Tx, id string, userID int64) (json.RawMessage, error) {
+	status, _, pausedNodes, err := r.lockForControl(ctx, tx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if isFinishedStatus(status) {
+		return nil, domain.ErrExecutionFinished
+	}
+
+	var debugMode bool
+	if err := tx.QueryRow(ctx, `
+		SELECT debug_mode FROM main.executions WHERE id = $1
+	`, id).Scan(&debugMode); err != nil {
+		return nil, fmt.Errorf("failed to get debug mode: %w", err)
+	}
+	if !debugMode {
+		return nil, domain.ErrExecutionNotDebug
+	}
+	if status != domain.ExecutionStatusPaused || len(pausedNodes) == 0 || string(pausedNodes) == "[]" {
+		return nil, domain.ErrExecutionNotPaused
+	}
+
+	return pausedNodes, nil
+}
+
+// lockForControl блокирует строку выполнения пользователя до конца транзакции
+// Возвращает статус, команду управления и отложенные сообщения
+func (r *ExecutionRepository) lockForControl(ctx context.Context, tx pgx.Tx, id string, userID int64) (int16, *string, json.RawMessage, error) {
+	executionID, err := uuid.Parse(id)
+	if err != nil {
+		return 0, nil, nil, domain.ErrExecutionNotFound
+	}
+
+	var status int16
+	var control *string
+	var pausedNodes json.RawMessage
+	err = tx.QueryRow(ctx, `
+		SELECT id_status, control, paused_nodes
+		FROM main.executions
+		WHERE id = $1 AND created_by = $2
+		FOR UPDATE
+	`, executionID, userID).Scan(&status, &control, &pausedNodes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil, nil, domain.ErrExecutionNotFound
+	}
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to lock execution: %w", err)
+	}
+
+	return status, control, pausedNodes, nil
+}
+
+// saveControlStep записывает действие управления в историю шагов (node_type = control, статус skipped)
+func (r *ExecutionRepository) saveControlStep(ctx context.Context, tx pgx.Tx, id string, action string, userID int64) error {
+	return r.saveControlStepOutput(ctx, tx, id, map[string]interface{}{
+		"action":  action,
+		"user_id": userID,
+	})
+}
+
+// saveControlStepOutput записывает шаг управления с произвольным output
+func (r *ExecutionRepository) saveControlStepOutput(ctx context.Context, tx pgx.Tx, id string, output map[string]interface{}) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO main.execution_steps (execution_id, node_id, node_type, output, id_status, started_at, finished_at)
+		SELECT id, COALESCE(current_step_id, ''), $2, $3, $4, NOW(), NOW()
+		FROM main.executions
+		WHERE id = $1
+	`, id, domain.NodeTypeControl, output, domain.StepStatusSkipped)
+	if err != nil {
+		return fmt.Errorf("failed to save control step: %w", err)
+	}
+	return nil
+}
+
+// isFinishedStatus - выполнение завершено и управлять им уже нельзя
+func isFinishedStatus(status int16) bool {
+	return status == domain.ExecutionStatusCompleted ||
+		status == domain.ExecutionStatusFailed ||
+		status == domain.ExecutionStatusStopped
+}
+
 // ExecutionRepository - репозиторий для работы с выполнениями схем
 // TODO: Реализовать методы:
 // - Create() - создать выполнение
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/piplexa/algomap/internal/domain"
 	"go.uber.org/zap"
 )
@@ -22,28 +23,36 @@ func NewSchemaRepository(db *DB, logger *zap.Logger) *SchemaRepository {
 	}
 }
 
-// Create создаёт новую схему
+// Create создаёт новую схему и её первую версию
 func (r *SchemaRepository) Create(ctx context.Context, req *domain.CreateSchemaRequest, createdBy int64) (*domain.Schema, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		INSERT INTO main.schemas (name, description, definition, id_status, created_by)
-		VALUES ($1, $2, $3, 2, $4)
-		RETURNING id, name, description, definition, id_status, created_by, created_at, updated_at
+		VALUES ($1, $2, $3, COALESCE($5, 2), $4)
+		RETURNING id, name, description, definition, id_status, version, created_by, created_at, updated_at
 	`
 
 	var schema domain.Schema
-	err := r.db.Pool.QueryRow(
+	err = tx.QueryRow(
 		ctx,
 		query,
 		req.Name,
 		req.Description,
 		req.Definition,
 		createdBy,
+		req.Status,
 	).Scan(
 		&schema.ID,
 		&schema.Name,
 		&schema.Description,
 		&schema.Definition,
 		&schema.Status,
+		&schema.Version,
 		&schema.CreatedBy,
 		&schema.CreatedAt,
 		&schema.UpdatedAt,
@@ -57,6 +66,14 @@ func (r *SchemaRepository) Create(ctx context.Context, req *domain.CreateSchemaR
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	if err := r.saveVersion(ctx, tx, &schema, createdBy, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	r.logger.Info("Schema created successfully",
 		zap.Int64("schema_id", schema.ID),
 		zap.String("name", schema.Name),
@@ -68,7 +85,7 @@ func (r *SchemaRepository) Create(ctx context.Context, req *domain.CreateSchemaR
 // GetByID получает схему по ID
 func (r *SchemaRepository) GetByID(ctx context.Context, id int64) (*domain.Schema, error) {
 	query := `
-		SELECT id, name, description, definition, id_status, created_by, created_at, updated_at
+		SELECT id, name, description, definition, id_status, version, created_by, created_at, updated_at
 		FROM main.schemas
 		WHERE id = $1
 	`
@@ -80,6 +97,7 @@ func (r *SchemaRepository) GetByID(ctx context.Context, id int64) (*domain.Schem
 		&schema.Description,
 		&schema.Definition,
 		&schema.Status,
+		&schema.Version,
 		&schema.CreatedBy,
 		&schema.CreatedAt,
 		&schema.UpdatedAt,
@@ -99,7 +117,7 @@ func (r *SchemaRepository) GetByID(ctx context.Context, id int64) (*domain.Schem
 // List возвращает список схем с опциональной фильтрацией
 func (r *SchemaRepository) List(ctx context.Context, status *int16, limit, offset int, id_user int64) ([]*domain.Schema, error) {
 	query := `
-		SELECT id, name, description, definition, id_status, created_by, created_at, updated_at
+		SELECT id, name, description, definition, id_status, version, created_by, created_at, updated_at
 		FROM main.schemas
 		WHERE ($1::SMALLINT IS NULL OR id_status = $1) and created_by = $4
 		ORDER BY created_at DESC
@@ -122,6 +140,7 @@ func (r *SchemaRepository) List(ctx context.Context, status *int16, limit, offse
 			&schema.Description,
 			&schema.Definition,
 			&schema.Status,
+			&schema.Version,
 			&schema.CreatedBy,
 			&schema.CreatedAt,
 			&schema.UpdatedAt,
@@ -142,22 +161,30 @@ func (r *SchemaRepository) List(ctx context.Context, status *int16, limit, offse
 }
 
 // Update обновляет схему
-func (r *SchemaRepository) Update(ctx context.Context, id int64, req *domain.UpdateSchemaRequest) (*domain.Schema, error) {
+// Если definition изменился - создаётся новая версия схемы от имени updatedBy
+func (r *SchemaRepository) Update(ctx context.Context, id int64, req *domain.UpdateSchemaRequest, updatedBy int64) (*domain.Schema, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	// Динамически строим запрос в зависимости от того, что нужно обновить
 	query := `
 		UPDATE main.schemas
 		SET 
 			name = COALESCE($2, name),
 			description = COALESCE($3, description),
+			version = CASE WHEN $4::JSONB IS NOT NULL AND $4::JSONB <> definition THEN version + 1 ELSE version END,
 			definition = COALESCE($4, definition),
 			id_status = COALESCE($5, id_status),
 			updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, description, definition, id_status, created_by, created_at, updated_at
+		RETURNING id, name, description, definition, id_status, version, created_by, created_at, updated_at
 	`
 
 	var schema domain.Schema
-	err := r.db.Pool.QueryRow(
+	err = tx.QueryRow(
 		ctx,
 		query,
 		id,
@@ -171,6 +198,7 @@ func (r *SchemaRepository) Update(ctx context.Context, id int64, req *domain.Upd
 		&schema.Description,
 		&schema.Definition,
 		&schema.Status,
+		&schema.Version,
 		&schema.CreatedBy,
 		&schema.CreatedAt,
 		&schema.UpdatedAt,
@@ -184,6 +212,14 @@ func (r *SchemaRepository) Update(ctx context.Context, id int64, req *domain.Upd
 		return nil, fmt.Errorf("failed to update schema: %w", err)
 	}
 
+	if err := r.saveVersion(ctx, tx, &schema, updatedBy, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	r.logger.Info("Schema updated successfully",
 		zap.Int64("schema_id", schema.ID),
 		zap.String("name", schema.Name),
@@ -212,4 +248,154 @@ func (r *SchemaRepository) Delete(ctx context.Context, id int64) error {
 	r.logger.Info("Schema deleted successfully", zap.Int64("schema_id", id))
 
 	return nil
-}
\ No newline at end of file
+}
+
+// Rollback делает definition версии version текущим
+// История не переписывается: создаётся новая версия с restored_from = version
+func (r *SchemaRepository) Rollback(ctx context.Context, id int64, version int, updatedBy int64) (*domain.Schema, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE main.schemas s
+		SET
+			version = CASE WHEN v.definition <> s.definition THEN s.version + 1 ELSE s.version END,
+			definition = v.definition,
+			updated_at = NOW()
+		FROM main.schema_versions v
+		WHERE s.id = $1 AND v.schema_id = s.id AND v.version = $2
+		RETURNING s.id, s.name, s.description, s.definition, s.id_status, s.version, s.created_by, s.created_at, s.updated_at
+	`
+
+	var schema domain.Schema
+	err = tx.QueryRow(ctx, query, id, version).Scan(
+		&schema.ID,
+		&schema.Name,
+		&schema.Description,
+		&schema.Definition,
+		&schema.Status,
+		&schema.Version,
+		&schema.CreatedBy,
+		&schema.CreatedAt,
+		&schema.UpdatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to roll back schema",
+			zap.Error(err),
+			zap.Int64("schema_id", id),
+			zap.Int("version", version),
+		)
+		return nil, fmt.Errorf("failed to roll back schema: %w", err)
+	}
+
+	if err := r.saveVersion(ctx, tx, &schema, updatedBy, &version); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Schema rolled back successfully",
+		zap.Int64("schema_id", schema.ID),
+		zap.Int("restored_from", version),
+		zap.Int("version", schema.Version),
+	)
+
+	return &schema, nil
+}
+
+// ListVersions возвращает версии схемы (без definition), новые первыми
+func (r *SchemaRepository) ListVersions(ctx context.Context, schemaID int64) ([]*domain.SchemaVersion, error) {
+	query := `
+		SELECT schema_id, version, restored_from, created_by, created_at
+		FROM main.schema_versions
+		WHERE schema_id = $1
+		ORDER BY version DESC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, schemaID)
+	if err != nil {
+		r.logger.Error("Failed to list schema versions", zap.Error(err), zap.Int64("schema_id", schemaID))
+		return nil, fmt.Errorf("failed to list schema versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make([]*domain.SchemaVersion, 0)
+	for rows.Next() {
+		var version domain.SchemaVersion
+		err := rows.Scan(
+			&version.SchemaID,
+			&version.Version,
+			&version.RestoredFrom,
+			&version.CreatedBy,
+			&version.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan schema version", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan schema version: %w", err)
+		}
+		versions = append(versions, &version)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating schema versions", zap.Error(err))
+		return nil, fmt.Errorf("error iterating schema versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersion получает версию схемы вместе с definition
+func (r *SchemaRepository) GetVersion(ctx context.Context, schemaID int64, version int) (*domain.SchemaVersion, error) {
+	query := `
+		SELECT schema_id, version, definition, restored_from, created_by, created_at
+		FROM main.schema_versions
+		WHERE schema_id = $1 AND version = $2
+	`
+
+	var v domain.SchemaVersion
+	err := r.db.Pool.QueryRow(ctx, query, schemaID, version).Scan(
+		&v.SchemaID,
+		&v.Version,
+		&v.Definition,
+		&v.RestoredFrom,
+		&v.CreatedBy,
+		&v.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to get schema version",
+			zap.Error(err),
+			zap.Int64("schema_id", schemaID),
+			zap.Int("version", version),
+		)
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return &v, nil
+}
+
+// saveVersion сохраняет текущий definition схемы как версию schema.Version
+// Если definition не менялся, такая версия уже есть - ничего не делает
+func (r *SchemaRepository) saveVersion(ctx context.Context, tx pgx.Tx, schema *domain.Schema, createdBy int64, restoredFrom *int) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO main.schema_versions (schema_id, version, definition, restored_from, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (schema_id, version) DO NOTHING
+	`, schema.ID, schema.Version, schema.Definition, restoredFrom, createdBy)
+	if err != nil {
+		r.logger.Error("Failed to save schema version",
+			zap.Error(err),
+			zap.Int64("schema_id", schema.ID),
+			zap.Int("version", schema.Version),
+		)
+		return fmt.Errorf("failed to save schema version: %w", err)
+	}
+
+	return nil
+}
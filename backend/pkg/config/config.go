@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -24,6 +26,17 @@ type Config struct {
 	// AT Scheduler
 	ATSchedulerURL string
 	URLExecution   string
+
+	// Sub schema
+	SubSchemaMaxDepth int
+
+	// Лимиты выполнения по умолчанию (переопределяются в main.users и в settings схемы)
+	ExecutionMaxSteps    int // максимум шагов одного выполнения
+	ExecutionMaxDuration int // максимальная длительность выполнения в секундах, 0 - без ограничения
+
+	// Именованные подключения к БД для db_query ноды (имя -> URL)
+	// Задаются переменными окружения DATASOURCE_<NAME>_URL, в схеме указывается только имя
+	DataSources map[string]string
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -38,6 +51,13 @@ func Load() (*Config, error) {
 		LogLevel:       getEnv("LOG_LEVEL", "info"),
 		ATSchedulerURL: getEnv("AT_SCHEDULER_URL", ""),
 		URLExecution:   getEnv("URL_EXECUTION", ""),
+
+		SubSchemaMaxDepth: getEnvInt("SUB_SCHEMA_MAX_DEPTH", 10),
+
+		ExecutionMaxSteps:    getEnvInt("EXECUTION_MAX_STEPS", 100),
+		ExecutionMaxDuration: getEnvInt("EXECUTION_MAX_DURATION", 0),
+
+		DataSources: loadDataSources(),
 	}
 
 	// Валидация обязательных параметров
@@ -54,4 +74,34 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
+}
+
+// loadDataSources собирает подключения из переменных DATASOURCE_<NAME>_URL
+// Имя приводится к нижнему регистру: DATASOURCE_ORDERS_URL -> "orders"
+func loadDataSources() map[string]string {
+	const prefix, suffix = "DATASOURCE_", "_URL"
+
+	sources := make(map[string]string)
+	for _, env := range os.Environ() {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || value == "" || !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, prefix), suffix)
+		if name == "" {
+			continue
+		}
+		sources[strings.ToLower(name)] = value
+	}
+	return sources
+}
+
+// getEnvInt читает целочисленную переменную окружения или возвращает defaultValue
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file
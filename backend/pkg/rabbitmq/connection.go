@@ -100,6 +100,25 @@ func (c *Connection) GetChannel() (*amqp091.Channel, error) {
 	return c.channel, nil
 }
 
+// OpenChannel открывает отдельный канал поверх текущего соединения
+// Используется там, где нельзя делить общий канал (например, publisher confirms)
+// Вызывающий обязан закрыть канал сам
+func (c *Connection) OpenChannel() (*amqp091.Channel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil, fmt.Errorf("connection is not initialized")
+	}
+
+	channel, err := c.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	return channel, nil
+}
+
 // Close закрывает соединение
 func (c *Connection) Close() error {
 	c.mu.Lock()
@@ -104,12 +104,12 @@ func (c *Consumer) handleMessage(ctx context.Context, msg amqp091.Delivery) {
 		return
 	}
 
-	// Выполняем ноду через engine (теперь возвращает nextNodeID)
-	nextNodeID, needContinue, err := c.engine.Execute(ctx, &execMsg)
+	// Выполняем ноду через engine (возвращает сообщения для публикации)
+	nextMessages, err := c.engine.Execute(ctx, &execMsg)
 	c.logger.Log(
 		-2,
 		"После выполнения ноды.",
-		zap.Bool("Надо ли продолжать выполнение схемы: ", *needContinue),
+		zap.Int("Сообщений для публикации: ", len(nextMessages)),
 	)
 	if err != nil {
 		c.logger.Error("failed to execute node",
@@ -117,23 +117,16 @@ func (c *Consumer) handleMessage(ctx context.Context, msg amqp091.Delivery) {
 			zap.String("node_id", execMsg.CurrentNodeID),
 			zap.Error(err),
 		)
-		return
+		// Сообщения могут вернуться и вместе с ошибкой (продолжение родительской схемы) - публикуем их
 	}
 
-	// Если есть следующая нода - публикуем новое сообщение
-	// Нода может быть sleep, тогда не нужно отправлять сообщение в очередь - это определяет needContinue
-	if nextNodeID != nil && *needContinue {
-		newMsg := &executor.ExecutionMessage{
-			ExecutionID:   execMsg.ExecutionID,
-			SchemaID:      execMsg.SchemaID,
-			CurrentNodeID: *nextNodeID,
-			DebugMode:     execMsg.DebugMode,
-		}
-
+	// Публикуем следующие ноды
+	// Нода может быть sleep, тогда движок не вернёт сообщений
+	for _, newMsg := range nextMessages {
 		if err := c.publisher.Publish(ctx, QueueName, newMsg); err != nil {
 			c.logger.Error("failed to publish next message",
-				zap.String("execution_id", execMsg.ExecutionID),
-				zap.String("next_node_id", *nextNodeID),
+				zap.String("execution_id", newMsg.ExecutionID),
+				zap.String("next_node_id", newMsg.CurrentNodeID),
 				zap.Error(err),
 			)
 			// TODO: Решить что делать если не удалось опубликовать
@@ -93,6 +93,66 @@ func (p *Publisher) Publish(ctx context.Context, queueName string, message inter
 	return nil
 }
 
+// PublishRaw публикует готовое сообщение в exchange с указанным routing key
+// Если confirm = true - сообщение публикуется в отдельном канале в режиме publisher confirms
+// и метод ждёт подтверждения от брокера. Возвращает true, если брокер подтвердил получение.
+func (p *Publisher) PublishRaw(ctx context.Context, exchange, routingKey string, msg amqp091.Publishing, confirm bool) (bool, error) {
+	if !confirm {
+		channel, err := p.conn.GetChannel()
+		if err != nil {
+			return false, fmt.Errorf("failed to get channel: %w", err)
+		}
+
+		if err := channel.PublishWithContext(ctx, exchange, routingKey, false, false, msg); err != nil {
+			p.logger.Error("Failed to publish message",
+				zap.Error(err),
+				zap.String("exchange", exchange),
+				zap.String("routing_key", routingKey),
+			)
+			return false, fmt.Errorf("failed to publish message: %w", err)
+		}
+
+		return false, nil
+	}
+
+	// Для confirms нужен отдельный канал: общий канал используется consumer'ом и publisher'ом схем
+	channel, err := p.conn.OpenChannel()
+	if err != nil {
+		return false, err
+	}
+	defer channel.Close()
+
+	if err := channel.Confirm(false); err != nil {
+		return false, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, msg)
+	if err != nil {
+		p.logger.Error("Failed to publish message",
+			zap.Error(err),
+			zap.String("exchange", exchange),
+			zap.String("routing_key", routingKey),
+		)
+		return false, fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to wait for publisher confirm: %w", err)
+	}
+	if !acked {
+		return false, fmt.Errorf("message was rejected by broker (nack)")
+	}
+
+	p.logger.Debug("Сообщение подтверждено брокером",
+		zap.String("exchange", exchange),
+		zap.String("routing_key", routingKey),
+		zap.Int("size", len(msg.Body)),
+	)
+
+	return true, nil
+}
+
 // PublishWithDelay публикует сообщение с задержкой (для Sleep ноды)
 // Требует плагин rabbitmq_delayed_message_exchange или использовать at library
 func (p *Publisher) PublishWithDelay(ctx context.Context, queueName string, message interface{}, delay time.Duration) error {
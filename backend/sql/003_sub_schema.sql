@@ -0,0 +1,20 @@
+-- =====================================================
+-- Migration: вызов схем из других схем (sub_schema)
+-- =====================================================
+
+-- Новый тип триггера - запуск из другой схемы
+INSERT INTO main.dict_trigger_type (id, name, description) VALUES
+    (5, 'sub_schema', 'Запуск из другой схемы (sub_schema нода)');
+
+-- Связь дочернего выполнения с родительским
+ALTER TABLE main.executions
+ADD COLUMN parent_execution_id UUID REFERENCES main.executions(id) ON DELETE SET NULL,
+ADD COLUMN parent_node_id VARCHAR(255),
+ADD COLUMN depth INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN main.executions.parent_execution_id IS 'Родительское выполнение (если запущено через sub_schema)';
+COMMENT ON COLUMN main.executions.parent_node_id IS 'ID sub_schema ноды родителя, которая ждёт завершения';
+COMMENT ON COLUMN main.executions.depth IS 'Глубина вложенности вызовов (0 - корневое выполнение)';
+COMMENT ON COLUMN main.executions.id_trigger_type IS '1=manual, 2=webhook, 3=scheduler, 4=api, 5=sub_schema';
+
+CREATE INDEX idx_executions_parent_execution_id ON main.executions(parent_execution_id) WHERE parent_execution_id IS NOT NULL;
@@ -0,0 +1,27 @@
+-- =====================================================
+-- Migration: параллельные ветки (parallel / join)
+-- =====================================================
+
+-- Количество активных веток выполнения
+ALTER TABLE main.execution_state
+ADD COLUMN active_branches INT NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN main.execution_state.active_branches IS 'Количество активных веток (parallel увеличивает, завершение ветки уменьшает)';
+
+-- Учёт веток, пришедших в join ноду
+CREATE TABLE main.execution_join_arrivals (
+    execution_id UUID NOT NULL REFERENCES main.executions(id),
+
+    -- ID join ноды
+    node_id VARCHAR(255) NOT NULL,
+
+    -- ID ноды, из которой пришла ветка
+    source_node_id VARCHAR(255) NOT NULL,
+
+    arrived_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (execution_id, node_id, source_node_id)
+);
+
+COMMENT ON TABLE main.execution_join_arrivals IS 'Ветки, пришедшие в join ноду (для слияния параллельных веток)';
+COMMENT ON COLUMN main.execution_join_arrivals.source_node_id IS 'Последняя нода ветки перед join';
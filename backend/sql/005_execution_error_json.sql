@@ -0,0 +1,14 @@
+-- =====================================================
+-- Migration: структурированная ошибка выполнения
+-- =====================================================
+
+-- Ошибка выполнения хранится объектом {code, message, details, node_id}
+-- Старые текстовые ошибки переносятся с кодом node_failed
+ALTER TABLE main.executions
+ALTER COLUMN error TYPE JSONB
+USING CASE
+    WHEN error IS NULL THEN NULL
+    ELSE jsonb_build_object('code', 'node_failed', 'message', error)
+END;
+
+COMMENT ON COLUMN main.executions.error IS 'Ошибка выполнения: {"code": "...", "message": "...", "details": ..., "node_id": "..."}';
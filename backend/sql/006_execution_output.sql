@@ -0,0 +1,8 @@
+-- =====================================================
+-- Migration: итоговый результат выполнения
+-- =====================================================
+
+ALTER TABLE main.executions
+ADD COLUMN output JSONB;
+
+COMMENT ON COLUMN main.executions.output IS 'Итоговый результат выполнения (output end ноды), возвращается как результат схемы';
@@ -0,0 +1,12 @@
+-- =====================================================
+-- Migration: лимиты выполнения пользователя
+-- =====================================================
+
+-- NULL - используется значение по умолчанию из конфигурации воркера
+-- (EXECUTION_MAX_STEPS, EXECUTION_MAX_DURATION); settings схемы важнее настроек пользователя
+ALTER TABLE main.users
+ADD COLUMN max_steps BIGINT,
+ADD COLUMN max_duration INT;
+
+COMMENT ON COLUMN main.users.max_steps IS 'Максимум шагов одного выполнения (NULL - значение по умолчанию)';
+COMMENT ON COLUMN main.users.max_duration IS 'Максимальная длительность выполнения в секундах (NULL - значение по умолчанию)';
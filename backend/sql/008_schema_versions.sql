@@ -0,0 +1,41 @@
+-- =====================================================
+-- Migration: версии схем
+-- =====================================================
+
+-- Текущая версия definition схемы
+ALTER TABLE main.schemas
+ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN main.schemas.version IS 'Номер текущей версии definition (main.schema_versions)';
+
+-- Неизменяемые версии definition: каждое сохранение с новым definition создаёт строку
+CREATE TABLE main.schema_versions (
+    schema_id BIGINT NOT NULL REFERENCES main.schemas(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    definition JSONB NOT NULL,
+
+    -- Версия, из которой восстановлена эта (rollback)
+    restored_from INT,
+
+    created_by BIGINT NOT NULL REFERENCES main.users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (schema_id, version)
+);
+
+COMMENT ON TABLE main.schema_versions IS 'История definition схем (строки не изменяются)';
+COMMENT ON COLUMN main.schema_versions.restored_from IS 'Номер версии, откат к которой создал эту версию';
+
+-- Существующие схемы - версия 1
+INSERT INTO main.schema_versions (schema_id, version, definition, created_by, created_at)
+SELECT id, 1, definition, created_by, updated_at FROM main.schemas;
+
+-- Версия схемы, с которой началось выполнение: движок всегда читает definition этой версии
+ALTER TABLE main.executions
+ADD COLUMN schema_version INT,
+ADD CONSTRAINT fk_executions_schema_version
+    FOREIGN KEY (schema_id, schema_version) REFERENCES main.schema_versions(schema_id, version);
+
+COMMENT ON COLUMN main.executions.schema_version IS 'Версия схемы, с которой запущено выполнение';
+
+UPDATE main.executions SET schema_version = 1;
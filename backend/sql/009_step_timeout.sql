@@ -0,0 +1,7 @@
+-- =====================================================
+-- Migration: статус шага "timeout"
+-- =====================================================
+
+-- Нода не уложилась в свой таймаут (data.timeout или таймаут типа ноды по умолчанию)
+INSERT INTO main.dict_step_status (id, name, description) VALUES
+    (4, 'timeout', 'Превышено время выполнения');
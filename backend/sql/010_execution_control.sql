@@ -0,0 +1,12 @@
+-- =====================================================
+-- Migration: пауза, продолжение и остановка выполнения
+-- =====================================================
+
+-- control - команда, которую воркер проверяет перед выполнением каждой ноды
+-- paused_nodes - сообщения очереди, отложенные на время паузы (их заново публикует resume)
+ALTER TABLE main.executions
+ADD COLUMN control VARCHAR(16),
+ADD COLUMN paused_nodes JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+COMMENT ON COLUMN main.executions.control IS 'Команда управления: pause - следующие ноды откладываются до resume';
+COMMENT ON COLUMN main.executions.paused_nodes IS 'Отложенные на паузе сообщения очереди (execution_id, current_node_id, prev_node_id...)';
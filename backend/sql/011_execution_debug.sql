@@ -0,0 +1,11 @@
+-- =====================================================
+-- Migration: пошаговая отладка выполнения
+-- =====================================================
+
+-- Выполнение запущено в режиме отладки: воркер останавливается на breakpoint нодах
+-- (или перед каждой нодой, если control = 'step'), позиция остановки - paused_nodes
+ALTER TABLE main.executions
+ADD COLUMN debug_mode BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN main.executions.debug_mode IS 'Выполнение в режиме отладки (останавливается на breakpoint нодах)';
+COMMENT ON COLUMN main.executions.control IS 'Команда управления: pause - следующие ноды откладываются до resume, step - (отладка) остановка перед каждой нодой';
@@ -0,0 +1,14 @@
+-- =====================================================
+-- Migration: форк выполнения с шага истории
+-- =====================================================
+
+-- Выполнение, созданное из шага другого выполнения (POST /api/executions/:id/steps/:step_id/fork)
+ALTER TABLE main.executions
+ADD COLUMN forked_from_execution_id UUID REFERENCES main.executions(id) ON DELETE SET NULL,
+ADD COLUMN forked_from_step_id BIGINT REFERENCES main.execution_steps(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN main.executions.forked_from_execution_id IS 'Выполнение, из шага которого создано это (форк)';
+COMMENT ON COLUMN main.executions.forked_from_step_id IS 'Шаг, контекст которого стал начальным контекстом форка';
+
+CREATE INDEX idx_executions_forked_from ON main.executions(forked_from_execution_id)
+WHERE forked_from_execution_id IS NOT NULL;
@@ -0,0 +1,43 @@
+-- =====================================================
+-- Migration: учёт веток join по запускам parallel
+-- =====================================================
+
+-- Ветки считаются по запуску parallel (fork) и номеру ветки в нём, а не по ноде, из которой пришли:
+-- две ветки через одну ноду - разные ветки, следующий проход через parallel - новый отсчёт.
+-- Старые записи переносятся как ветки без запуска (fork = ''), ключ - нода, из которой пришли
+ALTER TABLE main.execution_join_arrivals
+ADD COLUMN fork VARCHAR(64) NOT NULL DEFAULT '',
+ADD COLUMN branch VARCHAR(255);
+
+UPDATE main.execution_join_arrivals SET branch = source_node_id;
+
+ALTER TABLE main.execution_join_arrivals
+ALTER COLUMN branch SET NOT NULL,
+DROP CONSTRAINT execution_join_arrivals_pkey,
+ADD PRIMARY KEY (execution_id, node_id, fork, branch);
+
+COMMENT ON COLUMN main.execution_join_arrivals.fork IS 'Запуск parallel ноды, ветки которого сходятся в join (пусто - join не после parallel)';
+COMMENT ON COLUMN main.execution_join_arrivals.branch IS 'Номер ветки в запуске parallel (без запуска - ID ноды, из которой пришла ветка)';
+
+-- Сработавшие join: опоздавшие ветки того же запуска (N из M) ничего не отмечают и завершаются
+CREATE TABLE main.execution_join_fired (
+    execution_id UUID NOT NULL REFERENCES main.executions(id),
+
+    -- ID join ноды
+    node_id VARCHAR(255) NOT NULL,
+
+    -- Запуск parallel ноды
+    fork VARCHAR(64) NOT NULL,
+
+    fired_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (execution_id, node_id, fork)
+);
+
+COMMENT ON TABLE main.execution_join_fired IS 'Запуски parallel, для которых join уже сработал';
+
+-- Стеки веток, уснувших в sleep или ждущих дочернюю схему (сообщение продолжения приходит без них)
+ALTER TABLE main.execution_state
+ADD COLUMN suspended_branches JSONB NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN main.execution_state.suspended_branches IS 'Стеки веток parallel по ноде, с которой ветка продолжится после sleep или sub_schema';
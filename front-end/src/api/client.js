@@ -58,6 +58,10 @@ export const schemasAPI = {
 
   delete: (id) =>
     api.delete(`/schemas/${id}`),
+
+  // Выполнить одну ноду схемы: { context } или { step_id }
+  runNode: (id, nodeId, data = {}) =>
+    api.post(`/schemas/${id}/nodes/${nodeId}/run`, data),
 };
 
 // === Executions API ===
@@ -89,7 +93,44 @@ export const executionsAPI = {
 
   // Удаление всей истории выполнений по схеме
   deleteBySchemaId: (schemaId) =>
-    api.delete(`/executions/schema/${schemaId}`)
+    api.delete(`/executions/schema/${schemaId}`),
+
+  // Выполнить одну ноду схемы выполнения: { context } или { step_id }, по умолчанию - текущий контекст
+  runNode: (id, nodeId, data = {}) =>
+    api.post(`/executions/${id}/${nodeId}/one`, data),
+
+  // Управление выполнением
+  pause: (id) =>
+    api.post(`/executions/${id}/pause`),
+
+  resume: (id) =>
+    api.post(`/executions/${id}/resume`),
+
+  stop: (id) =>
+    api.post(`/executions/${id}/stop`),
+
+  // Новое выполнение из шага истории: { variables, debug_mode, debug_step }
+  fork: (id, stepId, data = {}) =>
+    api.post(`/executions/${id}/steps/${stepId}/fork`, data),
+
+  // Перезапуск упавшего выполнения, variables - новые значения переменных
+  retry: (id, variables) =>
+    api.post(`/executions/${id}/retry`, variables ? { variables } : {}),
+
+  debugStep: (id) =>
+    api.post(`/executions/${id}/debug/step`),
+
+  debugContinue: (id) =>
+    api.post(`/executions/${id}/debug/continue`),
+
+  getDebugVariables: (id) =>
+    api.get(`/executions/${id}/debug/variables`),
+
+  setDebugVariables: (id, variables) =>
+    api.put(`/executions/${id}/debug/variables`, { variables }),
+
+  debugAbort: (id) =>
+    api.post(`/executions/${id}/debug/abort`)
   };
 
 export default api;
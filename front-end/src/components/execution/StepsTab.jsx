@@ -29,7 +29,7 @@ export default function StepsTab({ steps, selectedStep, onSelectStep }) {
           <div className="step-row-header">
             <span className="step-icon">{getNodeIcon(step.node_type)}</span>
             <span className={`step-status ${step.status}`}>
-              {step.status === 'success' ? '✓' : '✗'}
+              {step.status === 'success' ? '✓' : step.status === 'timeout' ? '⏱' : '✗'}
             </span>
           </div>
           <div className="step-name">{step.node_id}</div>
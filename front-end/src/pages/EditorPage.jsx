@@ -315,6 +315,8 @@ export default function EditorPage() {
       description: schemaDescription,
       status: schemaStatus,
       definition: {
+        // settings (лимиты выполнения) редактор не меняет, но и не должен терять
+        ...(currentSchema?.definition?.settings && { settings: currentSchema.definition.settings }),
         nodes: cleanNodes,
         edges,
       },
@@ -324,6 +326,12 @@ export default function EditorPage() {
 
     if (result.success) {
       alert('✅ Схема сохранена!');
+    } else if (result.issues?.length) {
+      const problems = result.issues
+        .filter((issue) => issue.severity === 'error')
+        .map((issue) => `- ${issue.node_id || issue.edge_id || ''} ${issue.field || ''} ${issue.message}`)
+        .join('\n');
+      alert(`❌ ${result.error}\n${problems}`);
     } else {
       alert('❌ Ошибка сохранения');
     }
@@ -69,7 +69,8 @@ export const useSchemasStore = create((set, get) => ({
         error: error.response?.data?.message || 'Ошибка обновления схемы',
         loading: false 
       });
-      return { success: false };
+      // 422 - схема с ошибками не может быть активной, issues - список проблем
+      return { success: false, error: error.response?.data?.error, issues: error.response?.data?.issues };
     }
   },
 